@@ -0,0 +1,232 @@
+package asset
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mah0x211/github-release-admin/create"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+var ErrNotFound = fmt.Errorf("not found")
+
+// Target specifies the release that has the assets.
+type Target struct {
+	ReleaseID       int
+	Latest          bool
+	TagName         string
+	TargetCommitish string
+}
+
+func (t *Target) Release(ghc *github.Client) (*github.Release, error) {
+	var v *github.Release
+	var err error
+
+	switch {
+	case t.Latest:
+		v, err = ghc.GetReleaseLatest()
+	case t.TagName != "":
+		v, err = ghc.FindReleaseByTagName(t.TagName)
+		if v != nil && t.TargetCommitish != "" && v.TargetCommitish != t.TargetCommitish {
+			v = nil
+		}
+	default:
+		v, err = ghc.GetRelease(t.ReleaseID)
+	}
+
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+type Option struct {
+	ItemsPerPage int
+	AsRegex      bool
+	AsPosix      bool
+	DryRun       bool
+}
+
+func debugAsset(format string, v *github.Asset) error {
+	if log.Verbose {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to stringify the asset-info: %w", err)
+		}
+		log.Debug(format, v.ID, b)
+	}
+	return nil
+}
+
+func List(ghc *github.Client, t *Target, o *Option) ([]*github.Asset, error) {
+	v, err := t.Release(ghc)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*github.Asset{}
+	if err = ghc.FetchAsset(v.ID, 1, o.ItemsPerPage, func(a *github.Asset, _ int) error {
+		list = append(list, a)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func compileName(name string, o *Option) (*regexp.Regexp, error) {
+	var re *regexp.Regexp
+	var err error
+	if o.AsPosix {
+		re, err = regexp.CompilePOSIX(name)
+	} else if o.AsRegex {
+		re, err = regexp.Compile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%q cannot be compiled as regular expression: %w", name, err,
+		)
+	}
+	return re, nil
+}
+
+// find returns the asset with the specified name. it returns nil if not
+// found.
+func find(ghc *github.Client, v *github.Release, name string, o *Option) (*github.Asset, error) {
+	var found *github.Asset
+	if err := ghc.FetchAsset(v.ID, 1, o.ItemsPerPage, func(a *github.Asset, _ int) error {
+		if a.Name == name {
+			found = a
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Delete deletes the assets that match the name.
+func Delete(ghc *github.Client, t *Target, name string, o *Option) ([]*github.Asset, error) {
+	re, err := compileName(name, o)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := t.Release(ghc)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*github.Asset{}
+	if err = ghc.FetchAsset(v.ID, 1, o.ItemsPerPage, func(a *github.Asset, _ int) error {
+		if (re == nil && a.Name != name) || (re != nil && !re.MatchString(a.Name)) {
+			log.Debug("ignore asset that name does not matched to %q: %d", name, a.ID)
+			return nil
+		}
+		list = append(list, a)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// delete after listing not to change the pages
+	for i, a := range list {
+		if err = debugAsset("delete asset %d: %s", a); err != nil {
+			return list[:i], err
+		} else if !o.DryRun {
+			if err = ghc.DeleteAsset(a.ID); err != nil {
+				return list[:i], err
+			}
+		}
+	}
+	return list, nil
+}
+
+func update(ghc *github.Client, t *Target, name string, p *github.UpdateAssetParams, o *Option) (*github.Asset, error) {
+	v, err := t.Release(ghc)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := find(ghc, v, name, o)
+	if err != nil {
+		return nil, err
+	} else if a == nil {
+		return nil, ErrNotFound
+	} else if err = debugAsset("update asset %d: %s", a); err != nil {
+		return nil, err
+	} else if o.DryRun {
+		r := *a
+		if p.Name != nil {
+			r.Name = *p.Name
+		}
+		if p.Label != nil {
+			r.Label = *p.Label
+		}
+		return &r, nil
+	}
+
+	if a, err = ghc.UpdateAsset(a.ID, p); err != nil {
+		return nil, err
+	} else if a == nil {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+// Rename changes the name of the asset.
+func Rename(ghc *github.Client, t *Target, name, newName string, o *Option) (*github.Asset, error) {
+	return update(ghc, t, name, &github.UpdateAssetParams{
+		Name: &newName,
+	}, o)
+}
+
+// Label changes the label of the asset.
+func Label(ghc *github.Client, t *Target, name, label string, o *Option) (*github.Asset, error) {
+	return update(ghc, t, name, &github.UpdateAssetParams{
+		Label: &label,
+	}, o)
+}
+
+// Replace uploads the file as the asset named by the base name of the
+// pathname. the existing asset with the same name is replaced by
+// create.ReplaceAsset.
+func Replace(ghc *github.Client, t *Target, pathname string, o *Option) (*github.Asset, error) {
+	v, err := t.Release(ghc)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(pathname)
+	upload := func() error {
+		return create.Upload(ghc, v, pathname, &create.Option{
+			DryRun: o.DryRun,
+		})
+	}
+	old, err := find(ghc, v, name, o)
+	if err != nil {
+		return nil, err
+	} else if old == nil {
+		err = upload()
+	} else if err = debugAsset("replace asset %d: %s", old); err == nil {
+		err = create.ReplaceAsset(ghc, old, o.DryRun, upload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.DryRun {
+		return &github.Asset{Name: name}, nil
+	} else if a, err := find(ghc, v, name, o); err != nil {
+		return nil, err
+	} else if a == nil {
+		return nil, ErrNotFound
+	} else {
+		return a, nil
+	}
+}
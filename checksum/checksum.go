@@ -0,0 +1,219 @@
+package checksum
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// New returns the hash of the algorithm.
+func New(algo string) (hash.Hash, error) {
+	if fn, ok := algorithms[strings.ToLower(algo)]; ok {
+		return fn(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+}
+
+// ParseAlgorithms parses the comma-separated list of the algorithms.
+// (e.g. "sha256,sha512")
+func ParseAlgorithms(s string) ([]string, error) {
+	list := []string{}
+	exists := map[string]bool{}
+	for _, algo := range strings.Split(s, ",") {
+		algo = strings.ToLower(strings.TrimSpace(algo))
+		if algo == "" || exists[algo] {
+			continue
+		} else if _, ok := algorithms[algo]; !ok {
+			return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+		}
+		exists[algo] = true
+		list = append(list, algo)
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("checksum algorithm must not be empty")
+	}
+	return list, nil
+}
+
+// ManifestName returns the conventional filename of the manifest of the
+// algorithm. (e.g. "SHA256SUMS")
+func ManifestName(algo string) string {
+	return strings.ToUpper(algo) + "SUMS"
+}
+
+// Hasher computes the digests of multiple algorithms at once.
+type Hasher struct {
+	algos  []string
+	hashes []hash.Hash
+	w      io.Writer
+}
+
+func NewHasher(algos []string) (*Hasher, error) {
+	h := &Hasher{
+		algos: algos,
+	}
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		v, err := New(algo)
+		if err != nil {
+			return nil, err
+		}
+		h.hashes = append(h.hashes, v)
+		writers = append(writers, v)
+	}
+	h.w = io.MultiWriter(writers...)
+	return h, nil
+}
+
+func (h *Hasher) Write(b []byte) (int, error) {
+	return h.w.Write(b)
+}
+
+func (h *Hasher) Reset() {
+	for _, v := range h.hashes {
+		v.Reset()
+	}
+}
+
+// Sums returns the hex encoded digests of each algorithm.
+func (h *Hasher) Sums() map[string]string {
+	sums := map[string]string{}
+	for i, v := range h.hashes {
+		sums[h.algos[i]] = hex.EncodeToString(v.Sum(nil))
+	}
+	return sums
+}
+
+// Manifest is the list of the checksums in the GNU coreutils format.
+type Manifest struct {
+	Algorithm string
+	// Sums maps the filename to the hex encoded digest.
+	Sums map[string]string
+}
+
+func NewManifest(algo string) *Manifest {
+	return &Manifest{
+		Algorithm: strings.ToLower(algo),
+		Sums:      map[string]string{},
+	}
+}
+
+func (m *Manifest) Name() string {
+	return ManifestName(m.Algorithm)
+}
+
+// Bytes returns the manifest sorted by filename.
+//
+//	<hex-digest>  <filename>
+func (m *Manifest) Bytes() []byte {
+	names := make([]string, 0, len(m.Sums))
+	for name := range m.Sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := bytes.NewBuffer(nil)
+	for _, name := range names {
+		fmt.Fprintf(b, "%s  %s\n", m.Sums[name], name)
+	}
+	return b.Bytes()
+}
+
+var ErrMismatch = fmt.Errorf("checksum mismatch")
+
+// AlgorithmOf returns the algorithm guessed from the length of the hex
+// encoded digest.
+func AlgorithmOf(digest string) (string, error) {
+	if _, err := hex.DecodeString(digest); err == nil {
+		switch len(digest) {
+		case md5.Size * 2:
+			return "md5", nil
+		case sha1.Size * 2:
+			return "sha1", nil
+		case sha256.Size * 2:
+			return "sha256", nil
+		case sha512.Size * 2:
+			return "sha512", nil
+		}
+	}
+	return "", fmt.Errorf("invalid checksum %q", digest)
+}
+
+// Parse parses the manifest in the GNU coreutils format. the file that
+// contains only a digest is also accepted, and its digest is mapped to the
+// empty filename.
+//
+//	<hex-digest>  <filename>
+//	<hex-digest> *<filename>
+//	<hex-digest>
+func Parse(r io.Reader) (map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sums := map[string]string{}
+	for i, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name := ""
+		digest := line
+		if n := strings.IndexAny(line, " \t"); n != -1 {
+			digest = line[:n]
+			name = strings.TrimPrefix(strings.TrimLeft(line[n:], " \t"), "*")
+		}
+		if _, err := AlgorithmOf(digest); err != nil {
+			return nil, fmt.Errorf("invalid manifest at line %d: %w", i+1, err)
+		}
+		sums[name] = strings.ToLower(digest)
+	}
+	return sums, nil
+}
+
+// Verifier computes the digest of the written content and compares it with
+// the expected digest.
+type Verifier struct {
+	hash.Hash
+	algo   string
+	digest string
+}
+
+// NewVerifier returns the verifier of the hex encoded digest. the algorithm
+// is guessed from the length of the digest.
+func NewVerifier(digest string) (*Verifier, error) {
+	algo, err := AlgorithmOf(digest)
+	if err != nil {
+		return nil, err
+	}
+	h, _ := New(algo)
+	return &Verifier{
+		Hash:   h,
+		algo:   algo,
+		digest: strings.ToLower(digest),
+	}, nil
+}
+
+func (v *Verifier) Verify() error {
+	if sum := hex.EncodeToString(v.Sum(nil)); sum != v.digest {
+		return fmt.Errorf("%w: %s expected %s but got %s", ErrMismatch, v.algo, v.digest, sum)
+	}
+	return nil
+}
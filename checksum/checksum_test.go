@@ -0,0 +1,94 @@
+package checksum
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseAlgorithms(t *testing.T) {
+	// test that returns the list of algorithms without duplicates
+	list, err := ParseAlgorithms("SHA256, sha512,,sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sha256", "sha512"}, list)
+
+	// test that returns error
+	for _, v := range []string{"", " , ", "sha256,crc32"} {
+		list, err = ParseAlgorithms(v)
+		assert.Nil(t, list)
+		assert.Error(t, err)
+	}
+}
+
+func Test_Hasher(t *testing.T) {
+	h, err := NewHasher([]string{"sha256", "md5"})
+	assert.NoError(t, err)
+
+	// test that compute the digests of all algorithms
+	_, _ = h.Write([]byte("hello"))
+	assert.Equal(t, map[string]string{
+		"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		"md5":    "5d41402abc4b2a76b9719d911017c592",
+	}, h.Sums())
+
+	// test that reset the digests
+	h.Reset()
+	_, _ = h.Write([]byte("hello"))
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", h.Sums()["md5"])
+}
+
+func Test_Manifest(t *testing.T) {
+	m := NewManifest("SHA256")
+	m.Sums["foo.tar.gz"] = "abcd"
+	m.Sums["bar.zip"] = "0123"
+
+	// test that returns the manifest in GNU coreutils format
+	assert.Equal(t, "SHA256SUMS", m.Name())
+	assert.Equal(t, "0123  bar.zip\nabcd  foo.tar.gz\n", string(m.Bytes()))
+}
+
+func Test_Parse(t *testing.T) {
+	sha256 := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	md5 := "5d41402abc4b2a76b9719d911017c592"
+
+	// test that parse the GNU coreutils format
+	sums, err := Parse(strings.NewReader(
+		"# comment\n" + sha256 + "  foo.tar.gz\n" + strings.ToUpper(md5) + " *bar.zip\n\n",
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"foo.tar.gz": sha256,
+		"bar.zip":    md5,
+	}, sums)
+
+	// test that parse the file that contains only a digest
+	sums, err = Parse(strings.NewReader(sha256 + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"": sha256}, sums)
+
+	// test that returns error
+	sums, err = Parse(strings.NewReader("not-a-digest  foo.tar.gz\n"))
+	assert.Nil(t, sums)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func Test_Verifier(t *testing.T) {
+	// test that verify the digest
+	v, err := NewVerifier("2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824")
+	assert.NoError(t, err)
+	_, _ = v.Write([]byte("hello"))
+	assert.NoError(t, v.Verify())
+
+	// test that returns ErrMismatch
+	_, _ = v.Write([]byte("world"))
+	err = v.Verify()
+	assert.True(t, errors.Is(err, ErrMismatch))
+
+	// test that returns error for invalid digest
+	v, err = NewVerifier("abc")
+	assert.Nil(t, v)
+	assert.Error(t, err)
+}
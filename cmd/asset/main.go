@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/asset"
+	"github.com/mah0x211/github-release-admin/cmd"
+	"github.com/mah0x211/github-release-admin/getopt"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/util"
+)
+
+var exit = util.Exit
+
+func usage(code int) {
+	log.Print(`
+Manage release assets.
+
+Usage:
+    github-release-asset help
+    github-release-asset [<repo>] <release> list [--verbose]
+    github-release-asset [<repo>] <release> delete <name> [--verbose]
+                         [--no-dry-run] [--regex] [--posix]
+    github-release-asset [<repo>] <release> rename <name> <new-name>
+                         [--verbose] [--no-dry-run]
+    github-release-asset [<repo>] <release> label <name> <label>
+                         [--verbose] [--no-dry-run]
+    github-release-asset [<repo>] <release> replace <filename>
+                         [--verbose] [--no-dry-run]
+
+Arguments:
+    help                display help message.
+    <repo>              if the GITHUB_REPOSITORY environment variable is not
+                        defined, you must specify the target repository.
+    <release>           specify the release in one of the following formats;
+                          <release-id>: the release id. (greater than 0)
+                          latest: the latest release.
+                          by-tag <tag>[@<target>]: the release associated
+                          with the specified tag (and target).
+    <tag>               specify an existing tag. (e.g. v1.0.0)
+    <target>            specify a branch, or commish. (e.g. master)
+    list                list the assets of the release.
+    delete              delete the assets that match the <name>.
+    rename              change the name of the asset.
+    label               change the label of the asset.
+    replace             upload the file and overwrite the asset with the same
+                        name.
+    <name>              name of the asset.
+    <new-name>          new name of the asset.
+    <label>             new label of the asset.
+    <filename>          pathname of the file to upload.
+
+Options:
+    --verbose           display verbose output of the execution.
+    --no-dry-run        actually execute the request.
+    --regex             compile a <name> as regular expressions.
+    --posix             compile a <name> as POSIX ERE (egrep).
+
+Environment Variables:
+    GITHUB_TOKEN        required to access the private repository.
+    GITHUB_REPOSITORY   must be specified in the format "owner/repo".
+    GITHUB_API_URL      API URL. (default: "https://api.github.com")
+`)
+	exit(code)
+}
+
+func isNotEmptyString(s string) bool {
+	return strings.TrimSpace(s) != ""
+}
+
+type Option struct {
+	asset.Option
+	Args        []string
+	acceptRegex bool
+}
+
+func (o *Option) SetArg(arg string) bool {
+	if !isNotEmptyString(arg) {
+		log.Error("invalid arguments")
+		usage(1)
+	}
+	o.Args = append(o.Args, arg)
+	return true
+}
+
+func (o *Option) SetFlag(arg string) bool {
+	switch arg {
+	case "--verbose":
+		log.Verbose = true
+
+	case "--no-dry-run":
+		o.DryRun = false
+
+	case "--posix", "--regex":
+		if !o.acceptRegex {
+			log.Errorf("unknown option %q", arg)
+			usage(1)
+		}
+		o.AsRegex = true
+		o.AsPosix = o.AsPosix || arg == "--posix"
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+
+	return true
+}
+
+func (o *Option) SetKeyValue(k, v, arg string) bool {
+	log.Errorf("unknown option %q", arg)
+	usage(1)
+	return true
+}
+
+// parseTarget parses the <release> arguments and returns the rest.
+func parseTarget(args []string) (*asset.Target, []string) {
+	t := &asset.Target{}
+	if len(args) == 0 {
+		log.Error("invalid arguments")
+		usage(1)
+	}
+
+	switch args[0] {
+	case "latest":
+		t.Latest = true
+		return t, args[1:]
+
+	case "by-tag":
+		if len(args) > 1 {
+			arr := strings.Split(args[1], "@")
+			switch len(arr) {
+			case 1:
+				if isNotEmptyString(arr[0]) {
+					t.TagName = arr[0]
+					return t, args[2:]
+				}
+
+			case 2:
+				if isNotEmptyString(arr[0]) && isNotEmptyString(arr[1]) {
+					t.TagName = arr[0]
+					t.TargetCommitish = arr[1]
+					return t, args[2:]
+				}
+			}
+		}
+		log.Error("invalid <tag>[@<target>] arguments")
+		usage(1)
+
+	default:
+		// verify release-id
+		if v, err := strconv.ParseInt(args[0], 10, 64); err == nil && v > 0 {
+			t.ReleaseID = int(v)
+			return t, args[1:]
+		}
+		log.Error("invalid <release-id> argument")
+		usage(1)
+	}
+
+	return nil, nil
+}
+
+// parseOption parses the arguments of the command that requires nargs
+// arguments.
+func parseOption(args []string, nargs int, regex bool) *Option {
+	o := &Option{
+		acceptRegex: regex,
+	}
+	o.DryRun = true
+	getopt.Parse(o, args)
+	if len(o.Args) != nargs {
+		log.Error("invalid arguments")
+		usage(1)
+	}
+	return o
+}
+
+func start(ctx context.Context, ghc *github.Client, args []string) {
+	t, args := parseTarget(args)
+	if len(args) == 0 {
+		log.Error("invalid arguments")
+		usage(1)
+	}
+
+	var v interface{}
+	var err error
+
+	switch args[0] {
+	case "list":
+		o := parseOption(args[1:], 0, false)
+		v, err = asset.List(ghc, t, &o.Option)
+
+	case "delete":
+		o := parseOption(args[1:], 1, true)
+		v, err = asset.Delete(ghc, t, o.Args[0], &o.Option)
+
+	case "rename":
+		o := parseOption(args[1:], 2, false)
+		v, err = asset.Rename(ghc, t, o.Args[0], o.Args[1], &o.Option)
+
+	case "label":
+		o := parseOption(args[1:], 2, false)
+		v, err = asset.Label(ghc, t, o.Args[0], o.Args[1], &o.Option)
+
+	case "replace":
+		o := parseOption(args[1:], 1, false)
+		v, err = asset.Replace(ghc, t, o.Args[0], &o.Option)
+
+	default:
+		log.Errorf("unknown command %q", args[0])
+		usage(1)
+	}
+
+	if err != nil {
+		cmd.Fatalf("failed to %s asset: %v", args[0], err)
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to stringify the assets: %v", err)
+	}
+	log.Print(string(b))
+}
+
+func main() {
+	os.Exit(cmd.Start(start, usage))
+}
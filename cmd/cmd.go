@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,6 +15,29 @@ import (
 type StartFunc func(ctx context.Context, ghc *github.Client, args []string)
 type UsageFunc func(code int)
 
+var exit = util.Exit
+
+// Fatalf prints the formatted message to stderr and exits with code 1.
+// in verbose mode, it also prints the response dump of the github.APIError
+// passed as an argument.
+func Fatalf(format string, a ...interface{}) {
+	Exitf(1, format, a...)
+}
+
+// Exitf is the same as Fatalf but exits with the specified code.
+func Exitf(code int, format string, a ...interface{}) {
+	if log.Verbose {
+		for _, v := range a {
+			var e *github.APIError
+			if err, ok := v.(error); ok && errors.As(err, &e) {
+				log.Errorf("%s", e.Dump)
+			}
+		}
+	}
+	log.Errorf(format, a...)
+	exit(code)
+}
+
 func Start(startfn StartFunc, usagefn UsageFunc) int {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -22,7 +46,11 @@ func Start(startfn StartFunc, usagefn UsageFunc) int {
 	signal.Ignore()
 	signal.Notify(sigch, syscall.SIGINT, syscall.SIGTERM)
 
+	// done is closed after the startfn returns, so that the startfn can clean
+	// up after the context is canceled by the signal.
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		defer cancel()
 		args := os.Args[1:]
 		if len(args) > 0 && args[0] == "help" {
@@ -58,12 +86,12 @@ func Start(startfn StartFunc, usagefn UsageFunc) int {
 	}()
 
 	select {
-	case <-ctx.Done():
+	case <-done:
 	case sig := <-sigch:
 		log.Errorf("stop command by %s", sig)
 		cancel()
 		select {
-		case <-ctx.Done():
+		case <-done:
 		case sig = <-sigch:
 			log.Debug("stop command immediately by %s", sig)
 		}
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/mah0x211/github-release-admin/checksum"
 	"github.com/mah0x211/github-release-admin/cmd"
 	"github.com/mah0x211/github-release-admin/create"
 	"github.com/mah0x211/github-release-admin/getopt"
@@ -26,6 +29,8 @@ Usage:
            [--verbose] [--title=<title>] [--body=<body>]
            [--dir=<path/to/dir>] [--regex] [--posix]
            [--no-draft] [--no-prerelease] [--no-dry-run]
+           [--if-exists=<fail|skip|update|replace>] [--parallel=<num>]
+           [--checksums=<algo>[,<algo>...]] [--checksums-in-body]
 
 Arguments:
     help                display help message.
@@ -46,6 +51,22 @@ Options:
     --no-draft          save as non-draft release.
     --no-prerelease     save as non-prerelease (production ready).
     --no-dry-run        actually execute the request.
+    --if-exists=<fail|skip|update|replace>
+                        behavior when the release of the <tag> already
+                        exists. (default: fail)
+                          fail: fail to create the release.
+                          skip: do nothing.
+                          update: upload only the assets that do not exist
+                                  in the release.
+                          replace: upload all assets and overwrite the
+                                   assets with the same name.
+    --parallel=<num>    number of assets to upload concurrently. (default: 1)
+    --checksums=<algo>[,<algo>...]
+                        upload the checksum manifest of the assets for each
+                        algorithm. (e.g. SHA256SUMS)
+                        supported algorithms: md5, sha1, sha256, sha512
+    --checksums-in-body put the table of the checksums into the release body.
+                        the table of the previous run is replaced.
 
 Environment Variables:
     GITHUB_TOKEN        required to access the private repository.
@@ -120,6 +141,9 @@ func (o *Option) SetFlag(arg string) bool {
 	case "--no-dry-run":
 		o.DryRun = false
 
+	case "--checksums-in-body":
+		o.ChecksumsInBody = true
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -139,6 +163,30 @@ func (o *Option) SetKeyValue(k, v, arg string) bool {
 	case "--dir":
 		o.Dirname = v
 
+	case "--if-exists":
+		ie, err := create.ParseIfExists(v)
+		if err != nil {
+			log.Errorf("invalid --if-exists option: %v", err)
+			usage(1)
+		}
+		o.IfExists = ie
+
+	case "--checksums":
+		list, err := checksum.ParseAlgorithms(v)
+		if err != nil {
+			log.Errorf("invalid --checksums option: %v", err)
+			usage(1)
+		}
+		o.Checksums = list
+
+	case "--parallel":
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Errorf("--parallel must be greater than 0")
+			usage(1)
+		}
+		o.Parallel = n
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -146,6 +194,40 @@ func (o *Option) SetKeyValue(k, v, arg string) bool {
 	return true
 }
 
+// progress prints the progress of uploading each asset in 10% increments.
+// the progress is printed from the start again when the upload is retried.
+type progress struct {
+	sync.Mutex
+	steps map[string]*progressStep
+}
+
+type progressStep struct {
+	step int64
+	n    int64
+}
+
+func (p *progress) print(name string, n, size int64) {
+	step := int64(10)
+	if size > 0 {
+		step = n * 10 / size
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	last, ok := p.steps[name]
+	if !ok || n < last.n {
+		// the body has been rewound to retry the upload
+		last = &progressStep{step: -1}
+		p.steps[name] = last
+	}
+	last.n = n
+	if step <= last.step {
+		return
+	}
+	last.step = step
+	log.Debug("upload %s %d%% (%d/%d byte)", name, step*10, n, size)
+}
+
 func start(ctx context.Context, ghc *github.Client, args []string) {
 	o := &Option{}
 	o.Draft = true
@@ -156,6 +238,15 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 	if o.TagName == "" || o.Filename == "" {
 		log.Error("invalid arguments")
 		usage(1)
+	} else if o.ChecksumsInBody && len(o.Checksums) == 0 {
+		log.Error("--checksums-in-body requires --checksums option")
+		usage(1)
+	}
+	if log.Verbose {
+		p := &progress{
+			steps: map[string]*progressStep{},
+		}
+		o.Progress = p.print
 	}
 
 	// read asset files
@@ -181,7 +272,7 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 	}
 
 	if err = create.Release(ghc, assets, &o.Option); err != nil {
-		log.Fatalf("failed to create release: %v", err)
+		cmd.Fatalf("failed to create release: %v", err)
 	}
 }
 
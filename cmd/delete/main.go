@@ -6,12 +6,14 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mah0x211/github-release-admin/cmd"
 	"github.com/mah0x211/github-release-admin/delete"
 	"github.com/mah0x211/github-release-admin/getopt"
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
 	"github.com/mah0x211/github-release-admin/util"
 )
 
@@ -26,12 +28,25 @@ Usage:
     github-release-delete [<repo>] <release-id> [--verbose] [--no-dry-run]
     github-release-delete [<repo>] unbranched [--verbose] [--no-dry-run]
     github-release-delete [<repo>] draft [--verbose] [--no-dry-run]
-                          [--branch=<branch>]
+                          [--branch=<branch>] [--older-than=<age>]
+                          [--newer-than=<age>]
     github-release-delete [<repo>] prerelease [--verbose] [--no-dry-run]
-                          [--branch=<branch>]
+                          [--branch=<branch>] [--older-than=<age>]
+                          [--newer-than=<age>]
     github-release-delete [<repo>] by-tag <tag>[@<target>] [--verbose]
                           [--no-dry-run] [--regex] [--posix] [--draft]
-                          [--prerelease]
+                          [--prerelease] [--semver=<constraint>]
+                          [--older-than=<age>] [--newer-than=<age>]
+    github-release-delete [<repo>] by-tag --semver=<constraint> [--verbose]
+                          [--no-dry-run] [--draft] [--prerelease]
+                          [--older-than=<age>] [--newer-than=<age>]
+    github-release-delete [<repo>] orphan-tags [<tag>] [--verbose]
+                          [--no-dry-run] [--regex] [--posix]
+    github-release-delete [<repo>] prune --keep=<number> [--verbose]
+                          [--no-dry-run] [--group-by=<group>]
+                          [--order-by=<order>] [--branch=<branch>] [--draft]
+                          [--prerelease] [--older-than=<age>]
+                          [--newer-than=<age>]
 
 Arguments:
     help                display help message.
@@ -41,7 +56,13 @@ Arguments:
     draft               delete draft releases.
     unbranched          delete unbranched releases.
     prerelease          delete prereleases.
-    by-tag              delete a release with the specified tag.
+    by-tag              delete a release with the specified tag, or the
+                        releases that tag satisfies the --semver constraint.
+    prune               delete the releases except for the newest releases in
+                        each group.
+    orphan-tags         delete the tags that are not referenced by any
+                        release. if <tag> is specified, delete only the tags
+                        that match it.
     <tag>               specify an existing tag. (e.g. v1.0.0)
     <target>            specify a branch, or commish. (e.g. master)
 
@@ -54,6 +75,24 @@ Options:
     --posix             compile a <tag> as POSIX ERE (egrep).
     --draft             delete draft releases.
     --prerelease        delete prereleases.
+    --semver=<constraint>
+                        delete only the releases that tag is a semantic
+                        version that satisfies the constraint. the comparisons
+                        are separated by spaces. (e.g. ">=1.4 <2", "~1.2")
+    --keep=<number>     number of the newest releases to keep in each group.
+    --group-by=<group>  group the releases by "branch", "major" or "minor"
+                        version of the tag, or "none". (default: none)
+    --order-by=<order>  order the releases by "created" date or "semver" of
+                        the tag. the releases that tag is not a semantic
+                        version are kept. (default: created)
+    --older-than=<age>  delete only the releases published before the <age>.
+                        the releases that have not been published are
+                        compared by the created date. in prune mode, the
+                        releases that do not match are kept.
+    --newer-than=<age>  delete only the releases published after the <age>.
+    <age>               a duration before now with the units "s", "m", "h",
+                        "d" or "w" (e.g. 30d, 2w, 1d12h), or a date in the
+                        format "YYYY-MM-DD" or RFC3339.
 
 Environment Variables:
     GITHUB_TOKEN        required to access the private repository.
@@ -67,6 +106,20 @@ func isNotEmptyString(s string) bool {
 	return strings.TrimSpace(s) != ""
 }
 
+func setAge(a *delete.Age, k, v string) {
+	t, err := delete.ParseAge(v, time.Now())
+	if err != nil {
+		log.Errorf("invalid %s option: %v", k, err)
+		usage(1)
+	}
+
+	if k == "--older-than" {
+		a.OlderThan = t
+	} else {
+		a.NewerThan = t
+	}
+}
+
 type UnbranchedReleasesOption struct {
 	delete.UnbranchedReleasesOption
 }
@@ -130,6 +183,9 @@ func (o *DraftReleasesOption) SetKeyValue(k, v, arg string) bool {
 	case "--branch":
 		o.Branch = v
 
+	case "--older-than", "--newer-than":
+		setAge(&o.Age, k, v)
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -168,6 +224,9 @@ func (o *PreReleasesOption) SetKeyValue(k, v, arg string) bool {
 	case "--branch":
 		o.Branch = v
 
+	case "--older-than", "--newer-than":
+		setAge(&o.Age, k, v)
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -234,11 +293,135 @@ func (o *ReleasesByTagNameOption) SetFlag(arg string) bool {
 }
 
 func (o *ReleasesByTagNameOption) SetKeyValue(k, v, arg string) bool {
+	var err error
+
+	switch k {
+	case "--semver":
+		if o.Semver, err = semver.ParseConstraint(v); err != nil {
+			log.Errorf("invalid --semver option: %v", err)
+			usage(1)
+		}
+
+	case "--older-than", "--newer-than":
+		setAge(&o.Age, k, v)
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+	return true
+}
+
+type OrphanTagsOption struct {
+	delete.OrphanTagsOption
+}
+
+func (o *OrphanTagsOption) SetArg(arg string) bool {
+	if o.TagName == "" && isNotEmptyString(arg) {
+		o.TagName = arg
+		return true
+	}
+
+	log.Error("invalid arguments")
+	usage(1)
+	return true
+}
+
+func (o *OrphanTagsOption) SetFlag(arg string) bool {
+	switch arg {
+	case "--verbose":
+		log.Verbose = true
+
+	case "--posix":
+		o.AsPosix = true
+		fallthrough
+	case "--regex":
+		o.AsRegex = true
+
+	case "--no-dry-run":
+		o.DryRun = false
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+
+	return true
+}
+
+func (o *OrphanTagsOption) SetKeyValue(k, v, arg string) bool {
 	log.Errorf("unknown option %q", arg)
 	usage(1)
 	return true
 }
 
+type PruneReleasesOption struct {
+	delete.PruneReleasesOption
+}
+
+func (o *PruneReleasesOption) SetArg(arg string) bool {
+	log.Error("invalid arguments")
+	usage(1)
+	return true
+}
+
+func (o *PruneReleasesOption) SetFlag(arg string) bool {
+	switch arg {
+	case "--verbose":
+		log.Verbose = true
+
+	case "--draft":
+		o.Draft = true
+
+	case "--prerelease":
+		o.PreRelease = true
+
+	case "--no-dry-run":
+		o.DryRun = false
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+
+	return true
+}
+
+func (o *PruneReleasesOption) SetKeyValue(k, v, arg string) bool {
+	var err error
+
+	switch k {
+	case "--branch":
+		o.Branch = v
+
+	case "--older-than", "--newer-than":
+		setAge(&o.Age, k, v)
+
+	case "--keep":
+		if o.Keep, err = strconv.Atoi(v); err != nil || o.Keep < 0 {
+			log.Errorf("invalid --keep option %q", v)
+			usage(1)
+		}
+
+	case "--group-by":
+		if o.GroupBy, err = delete.ParseGroupBy(v); err != nil {
+			log.Errorf("invalid --group-by option: %v", err)
+			usage(1)
+		}
+
+	case "--order-by":
+		if o.OrderBy, err = delete.ParseOrderBy(v); err != nil {
+			log.Errorf("invalid --order-by option: %v", err)
+			usage(1)
+		}
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+	return true
+}
+
 type ReleaseOption struct {
 	delete.ReleaseOption
 }
@@ -317,12 +500,43 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 		o := &ReleasesByTagNameOption{}
 		o.DryRun = true
 		getopt.Parse(o, args[1:])
-		if o.TagName == "" {
+		if o.TagName == "" && o.Semver == nil {
 			log.Error("invalid arguments")
 			usage(1)
 		}
 		list, err = delete.ReleasesByTagName(ghc, &o.ReleasesByTagNameOption)
 
+	case "orphan-tags":
+		o := &OrphanTagsOption{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		tags, err := delete.OrphanTags(ghc, &o.OrphanTagsOption)
+		b, _ := json.MarshalIndent(tags, "", "  ")
+		log.Print(string(b))
+		if err != nil {
+			cmd.Fatalf("failed to delete tag: %v", err)
+		}
+		return
+
+	case "prune":
+		o := &PruneReleasesOption{}
+		o.DryRun = true
+		o.Keep = -1
+		getopt.Parse(o, args[1:])
+		if o.Keep < 0 {
+			log.Error("--keep option is required")
+			usage(1)
+		}
+		res, err := delete.PruneReleases(ghc, &o.PruneReleasesOption)
+		if res != nil {
+			b, _ := json.MarshalIndent(res, "", "  ")
+			log.Print(string(b))
+		}
+		if err != nil {
+			cmd.Fatalf("failed to delete release: %v", err)
+		}
+		return
+
 	default:
 		o := &ReleaseOption{}
 		o.DryRun = true
@@ -332,7 +546,7 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 			log.Error("invalid arguments")
 			usage(1)
 		} else if v, err := delete.Release(ghc, &o.ReleaseOption); err != nil {
-			log.Fatalf("failed to delete release: %v", err)
+			cmd.Fatalf("failed to delete release: %v", err)
 		} else if v != nil {
 			list = append(list, v)
 		}
@@ -341,7 +555,7 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 	b, _ := json.MarshalIndent(list, "", "  ")
 	log.Print(string(b))
 	if err != nil {
-		log.Fatalf("failed to delete release: %v", err)
+		cmd.Fatalf("failed to delete release: %v", err)
 	}
 }
 
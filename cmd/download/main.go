@@ -2,15 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
+	"github.com/mah0x211/github-release-admin/checksum"
 	"github.com/mah0x211/github-release-admin/cmd"
 	"github.com/mah0x211/github-release-admin/download"
 	"github.com/mah0x211/github-release-admin/getopt"
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
+	"github.com/mah0x211/github-release-admin/update"
 	"github.com/mah0x211/github-release-admin/util"
 )
 
@@ -18,31 +25,99 @@ var exit = util.Exit
 
 func usage(code int) {
 	log.Print(`
-Download a release asset.
+Download release assets.
 
 Usage:
     github-release-download help
-    github-release-download [<repo>] <release-id> <filename> [--verbose]
-                            [--no-dry-run]
-    github-release-download [<repo>] latest <filename> [--verbose] [--no-dry-run]
-    github-release-download [<repo>] by-tag <tag>[@<target>] <filename>
-                            [--verbose] [--no-dry-run]
+    github-release-download [<repo>] <release-id> [<filename>] [<options>...]
+    github-release-download [<repo>] latest [<filename>]
+                            [--semver=<constraint>] [<options>...]
+    github-release-download [<repo>] by-tag <tag>[@<target>] [<filename>]
+                            [<options>...]
+    github-release-download [<repo>] self-update <version> <path/to/executable>
+                            [--asset=<filename>] [--force] [<options>...]
 
 Arguments:
     help                display help message.
     <repo>              if the GITHUB_REPOSITORY environment variable is not
                         defined, you must specify the target repository.
     <release-id>        dowload from the specified release. (greater than 0)
-    <filename>          name of the asset to download.
-    latest              download from the lastest release.
+    <filename>          name of the asset to download. it can be omitted with
+                        the --all or --platform option.
+    latest              download from the lastest release. if the --semver
+                        option is specified, download from the release that
+                        has the highest semantic version that satisfies the
+                        constraint. (drafts and prereleases are ignored)
     by-tag              download from the release associated with the specified
                         tag (and target).
     <tag>               specify an existing tag. (e.g. v1.0.0)
     <target>            specify a branch, or commish. (e.g. master)
+    self-update         replace the executable with the asset of the latest
+                        release if the tag of the latest release is newer than
+                        the <version>. the asset is selected by the --platform
+                        option (default: auto), verified with the checksum
+                        manifest, and extracted if it is an archive. the
+                        previous executable is kept as "<path>.old".
+    <version>           current semantic version of the executable.
+    <path/to/executable>
+                        pathname of the executable to replace.
 
 Options:
     --verbose           display verbose output of the execution.
     --no-dry-run        actually execute the request.
+    --verify            verify the downloaded asset with the checksum manifest
+                        in the same release.
+    --checksum-file=<name>
+                        name of the checksum manifest. "<asset>" is replaced
+                        with the name of the asset.
+                        (default: <asset>.sha512, <asset>.sha256, SHA512SUMS,
+                        SHA256SUMS, checksums.txt)
+    --sha256=<hex>      verify the downloaded asset with the SHA256 digest.
+                        it cannot be used with --all, --regex or --posix.
+    --regex             compile <filename> as regular expressions.
+    --posix             compile <filename> as POSIX ERE (egrep).
+    --all               download all the assets that match the <filename>.
+    --dir=<path/to/dir> save the assets into this directory.
+    --output=<path/to/file>
+                        save the asset as this file. if "-" is specified, the
+                        asset is written to stdout and the result is written
+                        to stderr.
+    --connections=<number>
+                        download an asset in segments with the number of
+                        concurrent connections. (default: 1)
+    --extract[=<path/to/dir>]
+                        extract the downloaded archives (.tar, .tar.gz, .tgz,
+                        .zip and .gz) into this directory.
+                        (default: the directory of the --dir option)
+    --strip-components=<number>
+                        strip the number of leading components from the
+                        pathnames of the extracted files.
+    --member=<glob>     extract only the files that match the glob pattern.
+                        the pattern that does not contain "/" is matched
+                        against the base name. (e.g. "*/bin/tool", "tool")
+    --platform=<auto|goos/goarch>
+                        download the asset that best matches the platform
+                        from the assets that match the <filename>. "auto" is
+                        the platform of this program. (e.g. linux/amd64)
+
+Latest Options:
+    --semver=<constraint>
+                        the comparisons of the semantic version separated by
+                        spaces. (e.g. ">=1.4 <2", "~1.2", "^0.9")
+
+Self-Update Options:
+    --asset=<filename>  select the asset from the assets that match the
+                        <filename>. the --regex and --posix options are also
+                        available.
+    --force             update even if the latest version is not newer.
+
+Exit Status:
+    0                   success.
+    1                   invalid arguments or other errors.
+    2                   the release is not found.
+    3                   the asset is not found.
+    4                   the request failed by the network error, or the
+                        authentication or the authorization error.
 
 Environment Variables:
     GITHUB_TOKEN        required to access the private repository.
@@ -81,6 +156,21 @@ func (o *Option) SetFlag(arg string) bool {
 	case "--no-dry-run":
 		o.DryRun = false
 
+	case "--verify":
+		o.Verify = true
+
+	case "--posix":
+		o.AsPosix = true
+
+	case "--regex":
+		o.AsRegex = true
+
+	case "--all":
+		o.All = true
+
+	case "--extract":
+		o.Extract = true
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -90,8 +180,79 @@ func (o *Option) SetFlag(arg string) bool {
 }
 
 func (o *Option) SetKeyValue(k, v, arg string) bool {
-	log.Errorf("unknown option %q", arg)
-	usage(1)
+	switch k {
+	case "--checksum-file":
+		if !isNotEmptyString(v) {
+			log.Error("--checksum-file must not be empty")
+			usage(1)
+		}
+		o.Verify = true
+		o.ChecksumFile = v
+
+	case "--output":
+		if !isNotEmptyString(v) {
+			log.Error("--output must not be empty")
+			usage(1)
+		}
+		o.SaveAs = v
+
+	case "--dir":
+		if !isNotEmptyString(v) {
+			log.Error("--dir must not be empty")
+			usage(1)
+		}
+		o.Dirname = v
+
+	case "--extract":
+		if !isNotEmptyString(v) {
+			log.Error("--extract must not be empty")
+			usage(1)
+		}
+		o.Extract = true
+		o.ExtractDir = v
+
+	case "--connections":
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Errorf("invalid --connections option %q", v)
+			usage(1)
+		}
+		o.Connections = n
+
+	case "--strip-components":
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Errorf("invalid --strip-components option %q", v)
+			usage(1)
+		}
+		o.StripComponents = n
+
+	case "--member":
+		if _, err := path.Match(v, ""); err != nil || !isNotEmptyString(v) {
+			log.Errorf("invalid --member option %q", v)
+			usage(1)
+		}
+		o.Member = v
+
+	case "--platform":
+		p, err := download.ParsePlatform(v)
+		if err != nil {
+			log.Errorf("invalid --platform option: %v", err)
+			usage(1)
+		}
+		o.Platform = p
+
+	case "--sha256":
+		if algo, err := checksum.AlgorithmOf(v); err != nil || algo != "sha256" {
+			log.Errorf("invalid --sha256 option %q", v)
+			usage(1)
+		}
+		o.SHA256 = v
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
 	return true
 }
 
@@ -99,6 +260,19 @@ type LatestOption struct {
 	Option
 }
 
+func (o *LatestOption) SetKeyValue(k, v, arg string) bool {
+	if k == "--semver" {
+		c, err := semver.ParseConstraint(v)
+		if err != nil {
+			log.Errorf("invalid --semver option: %v", err)
+			usage(1)
+		}
+		o.Semver = c
+		return true
+	}
+	return o.Option.SetKeyValue(k, v, arg)
+}
+
 type TagOption struct {
 	Option
 	TagName         string
@@ -152,6 +326,116 @@ func (o *ReleaseOption) SetArg(arg string) bool {
 	return o.Option.SetArg(arg)
 }
 
+type SelfUpdateOption struct {
+	Option
+	CurrentVersion string
+	Pathname       string
+	Force          bool
+}
+
+func (o *SelfUpdateOption) SetArg(arg string) bool {
+	if !isNotEmptyString(arg) {
+		log.Error("invalid arguments")
+		usage(1)
+	} else if o.CurrentVersion == "" {
+		if _, err := semver.Parse(arg); err != nil {
+			log.Errorf("invalid <version> argument: %v", err)
+			usage(1)
+		}
+		o.CurrentVersion = arg
+	} else if o.Pathname == "" {
+		o.Pathname = arg
+	} else {
+		log.Error("invalid arguments")
+		usage(1)
+	}
+	return true
+}
+
+func (o *SelfUpdateOption) SetFlag(arg string) bool {
+	if arg == "--force" {
+		o.Force = true
+		return true
+	}
+	return o.Option.SetFlag(arg)
+}
+
+func (o *SelfUpdateOption) SetKeyValue(k, v, arg string) bool {
+	if k == "--asset" {
+		if !isNotEmptyString(v) {
+			log.Error("--asset must not be empty")
+			usage(1)
+		}
+		o.Filename = v
+		return true
+	}
+	return o.Option.SetKeyValue(k, v, arg)
+}
+
+const (
+	exitReleaseNotFound = 2
+	exitAssetNotFound   = 3
+	exitRequestFailed   = 4
+)
+
+// exitCode returns the exit status for the error.
+func exitCode(err error) int {
+	var ue *url.Error
+	switch {
+	case errors.Is(err, download.ErrReleaseNotFound):
+		return exitReleaseNotFound
+	case errors.Is(err, download.ErrAssetNotFound):
+		return exitAssetNotFound
+	case errors.As(err, &ue),
+		github.IsUnauthorized(err),
+		github.IsForbidden(err),
+		github.IsRateLimited(err):
+		return exitRequestFailed
+	}
+	return 1
+}
+
+func printResults(list []*download.Result, err error) {
+	if list != nil {
+		b, jerr := json.MarshalIndent(list, "", "  ")
+		if jerr != nil {
+			log.Fatalf("failed to stringify the results: %v", jerr)
+		}
+		log.Print(string(b))
+	}
+	if err != nil {
+		cmd.Exitf(exitCode(err), "failed to download: %v", err)
+	}
+}
+
+// checkSHA256 rejects the --sha256 option if multiple assets can be selected
+// since a digest can verify only one asset.
+func checkSHA256(o *Option) {
+	if o.SHA256 == "" {
+		return
+	} else if o.All {
+		log.Error("--sha256 cannot be used with --all")
+		usage(1)
+	} else if o.AsRegex || o.AsPosix {
+		log.Error("--sha256 cannot be used with --regex or --posix")
+		usage(1)
+	}
+}
+
+// setOutput writes the asset to stdout if the --output=- is specified.
+func setOutput(o *Option) {
+	if o.SaveAs != "-" {
+		return
+	} else if o.Extract {
+		log.Error("--extract cannot be used with --output=-")
+		usage(1)
+	}
+	o.SaveAs = ""
+	o.Writer = os.Stdout
+	// keep the stdout for the asset
+	log.Stdout = log.Stderr
+}
+
 func start(ctx context.Context, ghc *github.Client, args []string) {
 	arg := ""
 	if len(args) > 0 {
@@ -159,44 +443,69 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 	}
 
 	switch arg {
+	case "self-update":
+		o := &SelfUpdateOption{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		if o.CurrentVersion == "" || o.Pathname == "" {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		checkSHA256(&o.Option)
+		res, err := update.Update(ghc, o.CurrentVersion, o.Filename, o.Pathname, &update.Option{
+			Option: o.Option.Option,
+			Force:  o.Force,
+		})
+		if err != nil {
+			cmd.Exitf(exitCode(err), "failed to update: %v", err)
+		}
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to stringify the result: %v", err)
+		}
+		log.Print(string(b))
+
 	case "latest":
 		o := &LatestOption{}
 		o.DryRun = true
 		getopt.Parse(o, args[1:])
-		if o.Filename == "" {
+		if o.Filename == "" && !o.All && o.Platform == nil {
 			log.Error("invalid arguments")
 			usage(1)
-		} else if err := download.Latest(
-			ghc, o.Filename, &o.Option.Option,
-		); err != nil {
-			log.Fatalf("failed to download: %v", err)
 		}
+		checkSHA256(&o.Option)
+		setOutput(&o.Option)
+		printResults(download.Latest(
+			ghc, o.Filename, &o.Option.Option,
+		))
 
 	case "by-tag":
 		o := &TagOption{}
 		o.Option.DryRun = true
 		getopt.Parse(o, args[1:])
-		if o.Filename == "" || o.TagName == "" {
+		if (o.Filename == "" && !o.All && o.Platform == nil) || o.TagName == "" {
 			log.Error("invalid arguments")
 			usage(1)
-		} else if err := download.ByTagName(
-			ghc, o.TagName, o.TargetCommitish, o.Filename, &o.Option.Option,
-		); err != nil {
-			log.Fatalf("failed to download: %v", err)
 		}
+		checkSHA256(&o.Option)
+		setOutput(&o.Option)
+		printResults(download.ByTagName(
+			ghc, o.TagName, o.TargetCommitish, o.Filename, &o.Option.Option,
+		))
 
 	default:
 		o := &ReleaseOption{}
 		o.DryRun = true
 		getopt.Parse(o, args)
-		if o.Filename == "" || o.ReleaseID == 0 {
+		if (o.Filename == "" && !o.All && o.Platform == nil) || o.ReleaseID == 0 {
 			log.Error("invalid arguments")
 			usage(1)
-		} else if err := download.Release(
-			ghc, int(o.ReleaseID), o.Filename, &o.Option.Option,
-		); err != nil {
-			log.Fatalf("failed to download: %v", err)
 		}
+		checkSHA256(&o.Option)
+		setOutput(&o.Option)
+		printResults(download.Release(
+			ghc, int(o.ReleaseID), o.Filename, &o.Option.Option,
+		))
 	}
 }
 
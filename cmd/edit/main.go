@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/cmd"
+	"github.com/mah0x211/github-release-admin/edit"
+	"github.com/mah0x211/github-release-admin/getopt"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/util"
+)
+
+var exit = util.Exit
+
+func usage(code int) {
+	log.Print(`
+Edit release.
+
+Usage:
+    github-release-edit help
+    github-release-edit [<repo>] <release-id> [<options>...]
+    github-release-edit [<repo>] latest [<options>...]
+    github-release-edit [<repo>] by-tag <tag>[@<target>] [<options>...]
+
+Arguments:
+    help                display help message.
+    <repo>              if the GITHUB_REPOSITORY environment variable is not
+                        defined, you must specify the target repository.
+    <release-id>        edit the specified release. (greater than 0)
+    latest              edit the lastest release.
+    by-tag              edit the release associated with the specified tag
+                        (and target).
+    <tag>               specify an existing tag. (e.g. v1.0.0)
+    <target>            specify a branch, or commish. (e.g. master)
+
+Options:
+    --verbose           display verbose output of the execution.
+    --no-dry-run        actually execute the request.
+    --title=<title>     change the release title.
+    --body=<body>       change the description of the release.
+    --tag=<tag>         change the tag of the release.
+    --target=<target>   change the branch, or commitish of the release.
+    --draft             save as draft release.
+    --no-draft          save as non-draft release.
+    --prerelease        save as prerelease.
+    --no-prerelease     save as non-prerelease (production ready).
+    --latest            set the release as the latest release.
+    --no-latest         do not set the release as the latest release.
+
+Environment Variables:
+    GITHUB_TOKEN        required to access the private repository.
+    GITHUB_REPOSITORY   must be specified in the format "owner/repo".
+    GITHUB_API_URL      API URL. (default: "https://api.github.com")
+`)
+	exit(code)
+}
+
+func isNotEmptyString(s string) bool {
+	return strings.TrimSpace(s) != ""
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+type Option struct {
+	edit.Option
+}
+
+func (o *Option) SetArg(arg string) bool {
+	log.Error("invalid arguments")
+	usage(1)
+	return true
+}
+
+func (o *Option) SetFlag(arg string) bool {
+	switch arg {
+	case "--verbose":
+		log.Verbose = true
+
+	case "--no-dry-run":
+		o.DryRun = false
+
+	case "--draft":
+		o.Draft = boolPtr(true)
+
+	case "--no-draft":
+		o.Draft = boolPtr(false)
+
+	case "--prerelease":
+		o.PreRelease = boolPtr(true)
+
+	case "--no-prerelease":
+		o.PreRelease = boolPtr(false)
+
+	case "--latest":
+		o.MakeLatest = "true"
+
+	case "--no-latest":
+		o.MakeLatest = "false"
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+
+	return true
+}
+
+func (o *Option) SetKeyValue(k, v, arg string) bool {
+	switch k {
+	case "--title":
+		o.Name = &v
+
+	case "--body":
+		o.Body = &v
+
+	case "--tag":
+		if !isNotEmptyString(v) {
+			log.Error("--tag must not be empty")
+			usage(1)
+		}
+		o.TagName = &v
+
+	case "--target":
+		if !isNotEmptyString(v) {
+			log.Error("--target must not be empty")
+			usage(1)
+		}
+		o.TargetCommitish = &v
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+	return true
+}
+
+type TagOption struct {
+	Option
+	TagName         string
+	TargetCommitish string
+}
+
+func (o *TagOption) SetArg(arg string) bool {
+	if o.TagName == "" {
+		arr := strings.Split(arg, "@")
+		switch len(arr) {
+		case 1:
+			if isNotEmptyString(arr[0]) {
+				o.TagName = arr[0]
+				return true
+			}
+
+		case 2:
+			if isNotEmptyString(arr[0]) && isNotEmptyString(arr[1]) {
+				o.TagName = arr[0]
+				o.TargetCommitish = arr[1]
+				return true
+			}
+		}
+		log.Error("invalid <tag>[@<target>] arguments")
+		usage(1)
+	}
+
+	return o.Option.SetArg(arg)
+}
+
+type ReleaseOption struct {
+	Option
+	ReleaseID int64
+}
+
+func (o *ReleaseOption) SetArg(arg string) bool {
+	if o.ReleaseID == 0 {
+		// verify release-id
+		if v, err := strconv.ParseInt(arg, 10, 64); err == nil && v > 0 {
+			o.ReleaseID = v
+			return true
+		}
+		log.Error("invalid <release-id> argument")
+		usage(1)
+	}
+
+	return o.Option.SetArg(arg)
+}
+
+func start(ctx context.Context, ghc *github.Client, args []string) {
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
+	}
+
+	var v *github.Release
+	var err error
+
+	switch arg {
+	case "latest":
+		o := &Option{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		v, err = edit.Latest(ghc, &o.Option)
+
+	case "by-tag":
+		o := &TagOption{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		if o.TagName == "" {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		v, err = edit.ByTagName(ghc, o.TagName, o.TargetCommitish, &o.Option.Option)
+
+	default:
+		o := &ReleaseOption{}
+		o.DryRun = true
+		getopt.Parse(o, args)
+		if o.ReleaseID == 0 {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		v, err = edit.Release(ghc, int(o.ReleaseID), &o.Option.Option)
+	}
+
+	if err != nil {
+		cmd.Fatalf("failed to edit release: %v", err)
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to stringify the release: %v", err)
+	}
+	log.Print(string(b))
+}
+
+func main() {
+	os.Exit(cmd.Start(start, usage))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/list"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
 	"github.com/mah0x211/github-release-admin/util"
 )
 
@@ -22,11 +23,14 @@ List releases.
 Usage:
     github-release-list help
     github-release-list [<repo>] [--verbose] [--branch-exists]
-                        [--branch=<branch>]
+                        [--branch=<branch>] [--semver=<constraint>]
+                        [--sort=<order>]
     github-release-list [<repo>] draft [--verbose] [--branch-exists]
-                        [--branch=<branch>]
+                        [--branch=<branch>] [--semver=<constraint>]
+                        [--sort=<order>]
     github-release-list [<repo>] prerelease [--verbose] [--branch-exists]
-                        [--branch=<branch>]
+                        [--branch=<branch>] [--semver=<constraint>]
+                        [--sort=<order>]
 
 Arguments:
     help                display help message.
@@ -41,6 +45,12 @@ Options:
                         that exist.
     --branch=<branch>   lists only the releases associated with the
                         specified branch.
+    --semver=<constraint>
+                        lists only the releases that tag is a semantic version
+                        that satisfies the constraint. the comparisons are
+                        separated by spaces. (e.g. ">=1.4 <2", "~1.2", "^0.9")
+    --sort=<order>      sort the releases from newest to oldest by "semver" of
+                        the tag, "created" date or "published" date.
 
 Environment Variables:
     GITHUB_TOKEN        required to access the private repository.
@@ -76,10 +86,24 @@ func (o *Option) SetFlag(arg string) bool {
 }
 
 func (o *Option) SetKeyValue(k, v, arg string) bool {
+	var err error
+
 	switch k {
 	case "--branch":
 		o.Branch = v
 
+	case "--semver":
+		if o.Semver, err = semver.ParseConstraint(v); err != nil {
+			log.Errorf("invalid --semver option: %v", err)
+			usage(1)
+		}
+
+	case "--sort":
+		if o.Sort, err = list.ParseSortBy(v); err != nil {
+			log.Errorf("invalid --sort option: %v", err)
+			usage(1)
+		}
+
 	default:
 		log.Errorf("unknown option %q", arg)
 		usage(1)
@@ -105,7 +129,7 @@ func start(ctx context.Context, ghc *github.Client, args []string) {
 	getopt.Parse(o, args)
 	v, err := listfn(ghc, &o.Option)
 	if err != nil {
-		log.Fatalf("failed to list releases: %v", err)
+		cmd.Fatalf("failed to list releases: %v", err)
 	}
 
 	b, err := json.MarshalIndent(v, "", "  ")
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/cmd"
+	"github.com/mah0x211/github-release-admin/getopt"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/publish"
+	"github.com/mah0x211/github-release-admin/util"
+)
+
+var exit = util.Exit
+
+func usage(code int) {
+	log.Print(`
+Publish draft releases and promote prereleases.
+
+Usage:
+    github-release-publish help
+    github-release-publish [<repo>] <release-id> [<options>...]
+    github-release-publish [<repo>] by-tag <tag>[@<target>] [--regex] [--posix]
+                           [<options>...]
+    github-release-publish [<repo>] by-branch <branch> [<options>...]
+
+Arguments:
+    help                display help message.
+    <repo>              if the GITHUB_REPOSITORY environment variable is not
+                        defined, you must specify the target repository.
+    <release-id>        publish the specified release. (greater than 0)
+    by-tag              publish the releases associated with the specified
+                        tag (and target).
+    <tag>               specify an existing tag. (e.g. v1.0.0)
+    <target>            specify a branch, or commish. (e.g. master)
+    by-branch           publish the releases associated with the specified
+                        branch.
+    <branch>            specify a branch. (e.g. master)
+
+Options:
+    --verbose           display verbose output of the execution.
+    --no-dry-run        actually execute the request.
+    --regex             compile a <tag> as regular expressions.
+    --posix             compile a <tag> as POSIX ERE (egrep).
+    --draft             publish only the draft releases.
+    --prerelease        promote only the prereleases to the releases.
+                        (if neither --draft nor --prerelease is specified,
+                        both are applied)
+    --require=<name>    publish only the releases that have the specified
+                        assets. (comma-separated list) the command fails if
+                        any release does not have them.
+    --latest            set the published release as the latest release. the
+                        release that has already been published is also set
+                        if there is no release to publish.
+
+Environment Variables:
+    GITHUB_TOKEN        required to access the private repository.
+    GITHUB_REPOSITORY   must be specified in the format "owner/repo".
+    GITHUB_API_URL      API URL. (default: "https://api.github.com")
+`)
+	exit(code)
+}
+
+func isNotEmptyString(s string) bool {
+	return strings.TrimSpace(s) != ""
+}
+
+type Option struct {
+	publish.Option
+}
+
+func (o *Option) SetArg(arg string) bool {
+	log.Error("invalid arguments")
+	usage(1)
+	return true
+}
+
+func (o *Option) SetFlag(arg string) bool {
+	switch arg {
+	case "--verbose":
+		log.Verbose = true
+
+	case "--no-dry-run":
+		o.DryRun = false
+
+	case "--draft":
+		o.Draft = true
+
+	case "--prerelease":
+		o.PreRelease = true
+
+	case "--latest":
+		o.MakeLatest = true
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+
+	return true
+}
+
+func (o *Option) SetKeyValue(k, v, arg string) bool {
+	switch k {
+	case "--require":
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				o.RequiredAssets = append(o.RequiredAssets, name)
+			}
+		}
+
+	default:
+		log.Errorf("unknown option %q", arg)
+		usage(1)
+	}
+	return true
+}
+
+type TagOption struct {
+	Option
+}
+
+func (o *TagOption) SetArg(arg string) bool {
+	if o.TagName == "" {
+		arr := strings.Split(arg, "@")
+		switch len(arr) {
+		case 1:
+			if isNotEmptyString(arr[0]) {
+				o.TagName = arr[0]
+				return true
+			}
+
+		case 2:
+			if isNotEmptyString(arr[0]) && isNotEmptyString(arr[1]) {
+				o.TagName = arr[0]
+				o.Branch = arr[1]
+				return true
+			}
+		}
+		log.Error("invalid <tag>[@<target>] arguments")
+		usage(1)
+	}
+
+	return o.Option.SetArg(arg)
+}
+
+func (o *TagOption) SetFlag(arg string) bool {
+	switch arg {
+	case "--posix":
+		o.AsPosix = true
+		fallthrough
+	case "--regex":
+		o.AsRegex = true
+		return true
+	}
+
+	return o.Option.SetFlag(arg)
+}
+
+type BranchOption struct {
+	Option
+}
+
+func (o *BranchOption) SetArg(arg string) bool {
+	if o.Branch == "" {
+		if isNotEmptyString(arg) {
+			o.Branch = arg
+			return true
+		}
+		log.Error("invalid <branch> argument")
+		usage(1)
+	}
+
+	return o.Option.SetArg(arg)
+}
+
+type ReleaseOption struct {
+	Option
+	ReleaseID int64
+}
+
+func (o *ReleaseOption) SetArg(arg string) bool {
+	if o.ReleaseID == 0 {
+		// verify release-id
+		if v, err := strconv.ParseInt(arg, 10, 64); err == nil && v > 0 {
+			o.ReleaseID = v
+			return true
+		}
+		log.Error("invalid <release-id> argument")
+		usage(1)
+	}
+
+	return o.Option.SetArg(arg)
+}
+
+// setDefaults applies both of the --draft and --prerelease if neither is
+// specified.
+func setDefaults(o *publish.Option) {
+	if !o.Draft && !o.PreRelease {
+		o.Draft = true
+		o.PreRelease = true
+	}
+}
+
+func start(ctx context.Context, ghc *github.Client, args []string) {
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
+	}
+
+	list := []*github.Release{}
+	var err error
+
+	switch arg {
+	case "by-tag":
+		o := &TagOption{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		if o.TagName == "" {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		setDefaults(&o.Option.Option)
+		list, err = publish.Releases(ghc, &o.Option.Option)
+
+	case "by-branch":
+		o := &BranchOption{}
+		o.DryRun = true
+		getopt.Parse(o, args[1:])
+		if o.Branch == "" {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		setDefaults(&o.Option.Option)
+		list, err = publish.Releases(ghc, &o.Option.Option)
+
+	default:
+		o := &ReleaseOption{}
+		o.DryRun = true
+		getopt.Parse(o, args)
+		if o.ReleaseID == 0 {
+			log.Error("invalid arguments")
+			usage(1)
+		}
+		setDefaults(&o.Option.Option)
+		if v, err := publish.Release(ghc, int(o.ReleaseID), &o.Option.Option); err != nil {
+			cmd.Fatalf("failed to publish release: %v", err)
+		} else {
+			list = append(list, v)
+		}
+	}
+
+	b, _ := json.MarshalIndent(list, "", "  ")
+	log.Print(string(b))
+	if err != nil {
+		cmd.Fatalf("failed to publish release: %v", err)
+	}
+}
+
+func main() {
+	os.Exit(cmd.Start(start, usage))
+}
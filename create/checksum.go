@@ -0,0 +1,190 @@
+package create
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/checksum"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// checksumTable returns the markdown table of the checksums.
+func checksumTable(sums map[string]map[string]string, algos []string) string {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := bytes.NewBufferString("| File |")
+	for _, algo := range algos {
+		fmt.Fprintf(b, " %s |", strings.ToUpper(algo))
+	}
+	b.WriteString("\n| --- |" + strings.Repeat(" --- |", len(algos)) + "\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "| %s |", name)
+		for _, algo := range algos {
+			fmt.Fprintf(b, " `%s` |", sums[name][algo])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// checksumsBegin and checksumsEnd enclose the checksum table in the release
+// body to replace it when the release is updated.
+const (
+	checksumsBegin = "<!-- checksums:begin -->"
+	checksumsEnd   = "<!-- checksums:end -->"
+)
+
+// replaceChecksumTable replaces the checksum table in the body, or appends it
+// if the body does not contain the table.
+func replaceChecksumTable(body, table string) string {
+	section := checksumsBegin + "\n" + table + checksumsEnd
+	if i := strings.Index(body, checksumsBegin); i != -1 {
+		if j := strings.Index(body[i:], checksumsEnd); j != -1 {
+			return body[:i] + section + body[i+j+len(checksumsEnd):]
+		}
+	}
+
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + section
+}
+
+// existingChecksums returns the checksums of the assets that already exist in
+// the release. they are read from the existing manifests, and the assets that
+// are not listed in them are downloaded to compute the checksums. the assets
+// in the sums are ignored since they have been uploaded in this run.
+func existingChecksums(ghc *github.Client, v *github.Release, sums map[string]map[string]string, o *Option) (map[string]map[string]string, error) {
+	manifests := map[string]string{}
+	for _, algo := range o.Checksums {
+		manifests[checksum.ManifestName(algo)] = algo
+	}
+
+	names := map[string]bool{}
+	res := map[string]map[string]string{}
+	for _, a := range v.Assets {
+		names[a.Name] = true
+		algo, ok := manifests[a.Name]
+		if !ok {
+			continue
+		}
+		b := bytes.NewBuffer(nil)
+		if err := ghc.DownloadAssetTo(a.ID, b, nil); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", a.Name, err)
+		}
+		list, err := checksum.Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", a.Name, err)
+		}
+		for name, digest := range list {
+			if name == "" {
+				continue
+			} else if _, ok := res[name]; !ok {
+				res[name] = map[string]string{}
+			}
+			res[name][algo] = digest
+		}
+	}
+
+	for _, a := range v.Assets {
+		if _, ok := manifests[a.Name]; ok {
+			continue
+		} else if _, ok := sums[a.Name]; ok {
+			continue
+		} else if len(res[a.Name]) == len(o.Checksums) {
+			continue
+		}
+
+		log.Debug("download %s to compute the checksums", a.Name)
+		h, err := checksum.NewHasher(o.Checksums)
+		if err != nil {
+			return nil, err
+		} else if err = ghc.DownloadAssetTo(a.ID, h, nil); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", a.Name, err)
+		}
+		res[a.Name] = h.Sums()
+	}
+
+	// the manifests may list the assets that have been deleted
+	for name := range res {
+		if !names[name] {
+			delete(res, name)
+		}
+	}
+	return res, nil
+}
+
+// uploadChecksums uploads the checksum manifest of each algorithm, and puts
+// the checksum table into the release body if o.ChecksumsInBody is true. the
+// manifest contains the checksums of the existing assets and the uploaded
+// assets, and the existing manifest is replaced by ReplaceAsset.
+func uploadChecksums(ghc *github.Client, v *github.Release, sums map[string]map[string]string, o *Option) error {
+	if len(o.Checksums) == 0 || len(sums) == 0 {
+		return nil
+	}
+
+	all, err := existingChecksums(ghc, v, sums, o)
+	if err != nil {
+		return err
+	}
+	for name, sum := range sums {
+		all[name] = sum
+	}
+
+	existing := map[string]github.Asset{}
+	for _, a := range v.Assets {
+		existing[a.Name] = a
+	}
+
+	for _, algo := range o.Checksums {
+		m := checksum.NewManifest(algo)
+		for name, sum := range all {
+			m.Sums[name] = sum[algo]
+		}
+		name := m.Name()
+		b := m.Bytes()
+
+		upload := func() error {
+			log.Debug("upload %s %d byte:\n%s", name, len(b), b)
+			if o.DryRun {
+				return nil
+			} else if err := v.UploadAsset(
+				ghc, name, bytes.NewReader(b), int64(len(b)), "text/plain; charset=utf-8", nil,
+			); err != nil {
+				return fmt.Errorf("failed to upload %s: %w", name, err)
+			}
+			return nil
+		}
+
+		if a, ok := existing[name]; ok {
+			err = ReplaceAsset(ghc, &a, o.DryRun, upload)
+		} else {
+			err = upload()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !o.ChecksumsInBody {
+		return nil
+	}
+
+	body := replaceChecksumTable(v.Body, checksumTable(all, o.Checksums))
+	log.Debug("update release body:\n%s", body)
+	if !o.DryRun {
+		if _, err := ghc.UpdateRelease(v.ID, &github.UpdateReleaseParams{
+			Body: &body,
+		}); err != nil {
+			return fmt.Errorf("failed to put the checksums into the release body: %w", err)
+		}
+	}
+	return nil
+}
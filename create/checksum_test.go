@@ -0,0 +1,107 @@
+package create
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_replaceChecksumTable(t *testing.T) {
+	// test that append the table
+	body := replaceChecksumTable("release note", "| old |\n")
+	assert.Equal(t, "release note\n\n"+checksumsBegin+"\n| old |\n"+checksumsEnd, body)
+	assert.Equal(t, checksumsBegin+"\n| old |\n"+checksumsEnd, replaceChecksumTable("", "| old |\n"))
+
+	// test that replace the table
+	body = replaceChecksumTable(body+"\n\nfooter", "| new |\n")
+	assert.Equal(t, "release note\n\n"+checksumsBegin+"\n| new |\n"+checksumsEnd+"\n\nfooter", body)
+}
+
+const (
+	sumHello = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	sumOther = "d9298a10d1b0735837dc4bd85dac641b0f3cef27a47e5d53a54f2f3f5b2fcffa"
+	sumOld   = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+func Test_uploadChecksums(t *testing.T) {
+	requests := []string{}
+	manifest := ""
+	body := ""
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/owner/repo/releases/assets/10":
+			requests = append(requests, "download SHA256SUMS")
+			_, _ = w.Write([]byte(sumOld + "  foo.txt\n" + sumOld + "  gone.txt\n"))
+
+		case "GET /repos/owner/repo/releases/assets/11":
+			requests = append(requests, "download other.txt")
+			_, _ = w.Write([]byte("other"))
+
+		case "PATCH /repos/owner/repo/releases/assets/10":
+			p := &github.UpdateAssetParams{}
+			_ = json.NewDecoder(r.Body).Decode(p)
+			requests = append(requests, "rename "+*p.Name)
+			_ = json.NewEncoder(w).Encode(&github.Asset{ID: 10, Name: *p.Name})
+
+		case "DELETE /repos/owner/repo/releases/assets/10":
+			requests = append(requests, "delete SHA256SUMS")
+			w.WriteHeader(http.StatusNoContent)
+
+		case "POST /upload/1/assets":
+			b, _ := ioutil.ReadAll(r.Body)
+			manifest = string(b)
+			requests = append(requests, "upload "+r.URL.Query().Get("name"))
+			w.WriteHeader(http.StatusCreated)
+
+		case "PATCH /repos/owner/repo/releases/1":
+			p := &github.UpdateReleaseParams{}
+			_ = json.NewDecoder(r.Body).Decode(p)
+			body = *p.Body
+			_ = json.NewEncoder(w).Encode(&github.Release{ID: 1, Body: body})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	v := &github.Release{
+		ID:        1,
+		Body:      "note\n\n" + checksumsBegin + "\nstale\n" + checksumsEnd,
+		UploadURL: ts.URL + "/upload/1/assets{?name,label}",
+		Assets: []github.Asset{
+			{ID: 10, Name: "SHA256SUMS"},
+			{ID: 11, Name: "other.txt"},
+			{ID: 12, Name: "foo.txt"},
+		},
+	}
+	sums := map[string]map[string]string{
+		"foo.txt": {"sha256": sumHello},
+	}
+
+	// test that merge the checksums into the existing manifest and replace it
+	assert.NoError(t, uploadChecksums(ghc, v, sums, &Option{
+		IfExists:        IfExistsUpdate,
+		Checksums:       []string{"sha256"},
+		ChecksumsInBody: true,
+	}))
+	assert.Equal(t, []string{
+		"download SHA256SUMS",
+		"download other.txt",
+		"rename SHA256SUMS.replaced-10",
+		"upload SHA256SUMS",
+		"delete SHA256SUMS",
+	}, requests)
+	assert.Equal(t, sumHello+"  foo.txt\n"+sumOther+"  other.txt\n", manifest)
+	assert.True(t, strings.HasPrefix(body, "note\n\n"+checksumsBegin+"\n"), body)
+	assert.True(t, strings.HasSuffix(body, checksumsEnd), body)
+	assert.NotContains(t, body, "stale")
+	assert.Equal(t, 1, strings.Count(body, checksumsBegin))
+	assert.Contains(t, body, "| other.txt | `"+sumOther+"` |")
+}
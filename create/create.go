@@ -1,16 +1,38 @@
 package create
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
-	"os"
+	"fmt"
 	"path/filepath"
 
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
 )
 
+// IfExists specifies the behavior when the release of the tag already exists.
+type IfExists string
+
+const (
+	// IfExistsFail fails to create the release.
+	IfExistsFail IfExists = "fail"
+	// IfExistsSkip does nothing.
+	IfExistsSkip IfExists = "skip"
+	// IfExistsUpdate uploads only the assets that do not exist in the release.
+	IfExistsUpdate IfExists = "update"
+	// IfExistsReplace uploads all assets and overwrites the assets with the
+	// same name.
+	IfExistsReplace IfExists = "replace"
+)
+
+func ParseIfExists(s string) (IfExists, error) {
+	switch v := IfExists(s); v {
+	case IfExistsFail, IfExistsSkip, IfExistsUpdate, IfExistsReplace:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid value %q", s)
+}
+
 type Option struct {
 	TagName         string
 	TargetCommitish string
@@ -23,31 +45,72 @@ type Option struct {
 	Draft           bool
 	PreRelease      bool
 	DryRun          bool
+	IfExists        IfExists
+	// Parallel is the maximum number of assets uploaded concurrently.
+	// (default: 1)
+	Parallel int
+	// Checksums are the algorithms of the checksums computed while uploading
+	// the assets. the checksum manifest of each algorithm is uploaded as an
+	// additional asset. (e.g. SHA256SUMS)
+	Checksums []string
+	// ChecksumsInBody puts the table of the checksums into the release body.
+	// the table that has been put by the previous run is replaced.
+	ChecksumsInBody bool
+	// Progress is called with the progress of uploading each asset.
+	// it may be called concurrently if Parallel is greater than 1.
+	Progress func(name string, n, size int64)
 }
 
-func upload(ghc *github.Client, v *github.Release, pathname string, o *Option) error {
-	f, err := os.Open(pathname)
-	if err != nil {
-		return err
+// existingAssets returns the assets to be uploaded and the existing assets to
+// be overwritten by them.
+func existingAssets(v *github.Release, assets []string, o *Option) ([]string, map[string]github.Asset) {
+	names := map[string]github.Asset{}
+	for _, a := range v.Assets {
+		names[a.Name] = a
 	}
-	defer f.Close()
 
-	// detect content-length and content-type
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
-		return err
-	} else if _, err = f.Seek(0, 0); err != nil {
+	list := []string{}
+	replace := map[string]github.Asset{}
+	for _, pathname := range assets {
+		if a, ok := names[filepath.Base(pathname)]; !ok {
+			list = append(list, pathname)
+		} else if o.IfExists == IfExistsReplace {
+			list = append(list, pathname)
+			replace[pathname] = a
+		} else {
+			log.Debug("ignore asset that already exists: %s", a.Name)
+		}
+	}
+	return list, replace
+}
+
+// ReplaceAsset renames the existing asset to the temporary name, and then
+// calls the upload. the renamed asset is deleted if the upload succeeds, or
+// its name is restored if the upload fails.
+func ReplaceAsset(ghc *github.Client, a *github.Asset, dryrun bool, upload func() error) error {
+	log.Debug("replace asset %s (%d)", a.Name, a.ID)
+	if dryrun {
+		return upload()
+	}
+
+	name := a.Name
+	tmpName := fmt.Sprintf("%s.replaced-%d", name, a.ID)
+	if _, err := ghc.UpdateAsset(a.ID, &github.UpdateAssetParams{
+		Name: &tmpName,
+	}); err != nil {
 		return err
 	}
-	size := int64(len(b))
-	mime := http.DetectContentType(b)
-	name := filepath.Base(pathname)
 
-	log.Debug("upload %s %d byte (%s)", name, size, mime)
-	if !o.DryRun {
-		return v.UploadAsset(ghc, name, f, size, mime)
+	if err := upload(); err != nil {
+		// restore the name even if the context has been canceled
+		if _, rerr := ghc.WithContext(context.Background()).UpdateAsset(a.ID, &github.UpdateAssetParams{
+			Name: &name,
+		}); rerr != nil {
+			log.Errorf("failed to restore the asset %d: %v", a.ID, rerr)
+		}
+		return err
 	}
-	return nil
+	return ghc.DeleteAsset(a.ID)
 }
 
 func Release(ghc *github.Client, assets []string, o *Option) error {
@@ -55,10 +118,54 @@ func Release(ghc *github.Client, assets []string, o *Option) error {
 		return nil
 	}
 
+	var v *github.Release
+	var err error
+	if o.IfExists != "" && o.IfExists != IfExistsFail {
+		if v, err = ghc.FindReleaseByTagName(o.TagName); err != nil {
+			return err
+		}
+	}
+
+	if v != nil {
+		return updateRelease(ghc, v, assets, o)
+	}
+	return createRelease(ghc, assets, o)
+}
+
+func updateRelease(ghc *github.Client, v *github.Release, assets []string, o *Option) error {
+	if o.IfExists == IfExistsSkip {
+		log.Printf("release %d of the tag %q already exists", v.ID, o.TagName)
+		return nil
+	}
+
+	assets, replace := existingAssets(v, assets, o)
+	if log.Verbose {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		log.Debug("update release %s", b)
+	}
+
+	sums, err := uploadAssets(ghc, v, assets, replace, o)
+	if err != nil {
+		return err
+	}
+	return uploadChecksums(ghc, v, sums, o)
+}
+
+func createRelease(ghc *github.Client, assets []string, o *Option) error {
 	var v *github.Release
 	var err error
 	if o.DryRun {
-		v = &github.Release{}
+		v = &github.Release{
+			TagName:         o.TagName,
+			TargetCommitish: o.TargetCommitish,
+			Name:            o.Title,
+			Body:            o.Body,
+			Draft:           o.Draft,
+			PreRelease:      o.PreRelease,
+		}
 	} else if v, err = ghc.CreateRelease(
 		o.TagName, o.TargetCommitish, o.Title, o.Body, o.Draft, o.PreRelease,
 	); err != nil {
@@ -74,15 +181,18 @@ func Release(ghc *github.Client, assets []string, o *Option) error {
 	}
 
 	// upload asset files
-	for _, pathname := range assets {
-		if err = upload(ghc, v, pathname, o); err != nil {
-			if !o.DryRun {
-				if err := ghc.DeleteRelease(v.ID); err != nil {
-					log.Errorf("failed to delete the failed release: %v", err)
-				}
+	sums, err := uploadAssets(ghc, v, assets, nil, o)
+	if err == nil {
+		err = uploadChecksums(ghc, v, sums, o)
+	}
+	if err != nil {
+		if !o.DryRun {
+			// delete the release even if the context has been canceled
+			if err := ghc.WithContext(context.Background()).DeleteRelease(v.ID); err != nil {
+				log.Errorf("failed to delete the failed release: %v", err)
 			}
-			return err
 		}
+		return err
 	}
 
 	return nil
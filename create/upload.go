@@ -0,0 +1,226 @@
+package create
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mah0x211/github-release-admin/checksum"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// Upload uploads the file to the release as an asset named by the base name
+// of the pathname.
+func Upload(ghc *github.Client, v *github.Release, pathname string, o *Option) error {
+	_, err := upload(ghc, v, pathname, o)
+	return err
+}
+
+// hashingReader computes the digests of the content read from the file.
+// the digests are reset when the file is rewound to retry the upload.
+type hashingReader struct {
+	f *os.File
+	h *checksum.Hasher
+	n int64
+}
+
+func (r *hashingReader) Read(b []byte) (int, error) {
+	n, err := r.f.Read(b)
+	if n > 0 {
+		_, _ = r.h.Write(b[:n])
+		r.n += int64(n)
+	}
+	return n, err
+}
+
+func (r *hashingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.f.Seek(offset, whence)
+	if err == nil && pos == 0 {
+		r.h.Reset()
+		r.n = 0
+	}
+	return pos, err
+}
+
+// upload uploads the file and returns the checksums of the o.Checksums
+// algorithms computed while streaming it.
+func upload(ghc *github.Client, v *github.Release, pathname string, o *Option) (map[string]string, error) {
+	f, err := os.Open(pathname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// detect content-length and content-type without reading whole file
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	} else if !stat.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", pathname)
+	}
+	size := stat.Size()
+
+	b := make([]byte, 512)
+	n, err := io.ReadFull(f, b)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	} else if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	mime := http.DetectContentType(b[:n])
+	name := filepath.Base(pathname)
+
+	var body io.Reader = f
+	var hr *hashingReader
+	if len(o.Checksums) > 0 {
+		h, err := checksum.NewHasher(o.Checksums)
+		if err != nil {
+			return nil, err
+		}
+		hr = &hashingReader{
+			f: f,
+			h: h,
+		}
+		body = hr
+	}
+
+	log.Debug("upload %s %d byte (%s)", name, size, mime)
+	if o.DryRun {
+		if hr != nil {
+			// compute the checksums without uploading
+			if _, err = io.Copy(ioutil.Discard, hr); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var progress github.ProgressFunc
+		if o.Progress != nil {
+			progress = func(n, size int64) {
+				o.Progress(name, n, size)
+			}
+		}
+		if err = v.UploadAsset(ghc, name, body, size, mime, progress); err != nil {
+			return nil, err
+		}
+	}
+
+	if hr == nil {
+		return nil, nil
+	} else if hr.n != size {
+		return nil, fmt.Errorf("unable to compute the checksums of %d/%d byte", hr.n, size)
+	}
+	return hr.h.Sums(), nil
+}
+
+// UploadError is the error that occurred in uploading the asset file.
+type UploadError struct {
+	Pathname string
+	Err      error
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pathname, e.Err)
+}
+
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// UploadErrors is the list of errors that occurred in uploading the asset
+// files concurrently.
+type UploadErrors []*UploadError
+
+func (e UploadErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	list := make([]string, 0, len(e))
+	for _, err := range e {
+		list = append(list, err.Error())
+	}
+	return fmt.Sprintf("failed to upload %d files: %s", len(e), strings.Join(list, ", "))
+}
+
+// uploadAssets uploads the asset files with up to o.Parallel workers and
+// returns the checksums of each asset. if the replace map contains the
+// pathname, the asset is replaced by ReplaceAsset. it stops
+// dispatching the files when any upload fails or the context of the client is
+// canceled.
+func uploadAssets(ghc *github.Client, v *github.Release, assets []string, replace map[string]github.Asset, o *Option) (map[string]map[string]string, error) {
+	nworker := o.Parallel
+	if nworker < 1 {
+		nworker = 1
+	} else if nworker > len(assets) {
+		nworker = len(assets)
+	}
+
+	var mu sync.Mutex
+	var errs UploadErrors
+	sums := map[string]map[string]string{}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}
+
+	var wg sync.WaitGroup
+	ch := make(chan string)
+	for i := 0; i < nworker; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pathname := range ch {
+				var err error
+				var sum map[string]string
+				if a, ok := replace[pathname]; ok {
+					err = ReplaceAsset(ghc, &a, o.DryRun, func() (uerr error) {
+						sum, uerr = upload(ghc, v, pathname, o)
+						return uerr
+					})
+				} else {
+					sum, err = upload(ghc, v, pathname, o)
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, &UploadError{
+						Pathname: pathname,
+						Err:      err,
+					})
+				} else if sum != nil {
+					sums[filepath.Base(pathname)] = sum
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	ctx := ghc.Context()
+dispatch:
+	for _, pathname := range assets {
+		if failed() {
+			break
+		}
+		select {
+		case ch <- pathname:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
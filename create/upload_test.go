@@ -0,0 +1,111 @@
+package create
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type assetServer struct {
+	mu       sync.Mutex
+	fail     bool
+	requests []string
+}
+
+func (s *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/releases/assets/1":
+		p := &github.UpdateAssetParams{}
+		_ = json.NewDecoder(r.Body).Decode(p)
+		s.requests = append(s.requests, "rename "+*p.Name)
+		_ = json.NewEncoder(w).Encode(&github.Asset{ID: 1, Name: *p.Name})
+
+	case r.Method == "DELETE" && r.URL.Path == "/repos/owner/repo/releases/assets/1":
+		s.requests = append(s.requests, "delete")
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "POST" && r.URL.Path == "/upload/1/assets":
+		_, _ = ioutil.ReadAll(r.Body)
+		s.requests = append(s.requests, "upload "+r.URL.Query().Get("name"))
+		if s.fail {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_existingAssets(t *testing.T) {
+	v := &github.Release{
+		Assets: []github.Asset{{ID: 1, Name: "foo.txt"}},
+	}
+	assets := []string{"dir/foo.txt", "dir/bar.txt"}
+
+	// test that ignore the existing assets
+	list, replace := existingAssets(v, assets, &Option{IfExists: IfExistsUpdate})
+	assert.Equal(t, []string{"dir/bar.txt"}, list)
+	assert.Empty(t, replace)
+
+	// test that replace the existing assets
+	list, replace = existingAssets(v, assets, &Option{IfExists: IfExistsReplace})
+	assert.Equal(t, assets, list)
+	assert.Equal(t, map[string]github.Asset{"dir/foo.txt": v.Assets[0]}, replace)
+}
+
+func Test_uploadAssets_replace(t *testing.T) {
+	s := &assetServer{}
+	ghc, ts := testutil.NewClient(t, s)
+	defer ts.Close()
+
+	pathname := filepath.Join(t.TempDir(), "foo.txt")
+	assert.NoError(t, ioutil.WriteFile(pathname, []byte("hello"), 0644))
+	v := &github.Release{ID: 1, UploadURL: ts.URL + "/upload/1/assets{?name,label}"}
+	replace := map[string]github.Asset{
+		pathname: {ID: 1, Name: "foo.txt"},
+	}
+
+	// test that restore the original asset if the upload fails
+	s.fail = true
+	_, err := uploadAssets(ghc, v, []string{pathname}, replace, &Option{})
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"rename foo.txt.replaced-1",
+		"upload foo.txt",
+		"rename foo.txt",
+	}, s.requests)
+
+	// test that delete the original asset after the upload succeeds
+	s.fail = false
+	s.requests = nil
+	sums, err := uploadAssets(ghc, v, []string{pathname}, replace, &Option{
+		Checksums: []string{"sha256"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"rename foo.txt.replaced-1",
+		"upload foo.txt",
+		"delete",
+	}, s.requests)
+	assert.Equal(t, map[string]map[string]string{
+		"foo.txt": {"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+	}, sums)
+
+	// test that does not call the API in dry-run
+	s.requests = nil
+	_, err = uploadAssets(ghc, v, []string{pathname}, replace, &Option{DryRun: true})
+	assert.NoError(t, err)
+	assert.Empty(t, s.requests)
+}
@@ -0,0 +1,72 @@
+package delete
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// Age filters the releases by the published_at, or the created_at if the
+// release has not been published. the zero value does not filter.
+type Age struct {
+	// OlderThan matches the releases that released before this time.
+	OlderThan time.Time
+	// NewerThan matches the releases that released after this time.
+	NewerThan time.Time
+}
+
+func (a *Age) match(v *github.Release) bool {
+	t := v.ReleasedAt()
+	if !a.OlderThan.IsZero() && !t.Before(a.OlderThan) {
+		log.Debug("ignore release that is not older than %s: %d", a.OlderThan.Format(time.RFC3339), v.ID)
+		return false
+	} else if !a.NewerThan.IsZero() && !t.After(a.NewerThan) {
+		log.Debug("ignore release that is not newer than %s: %d", a.NewerThan.Format(time.RFC3339), v.ID)
+		return false
+	}
+	return true
+}
+
+var reDuration = regexp.MustCompile(`(\d+)([smhdw])`)
+
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+// ParseAge parses the duration (e.g. 30d, 2w, 1d12h) or the date (e.g.
+// 2006-01-02 or RFC3339) and returns the time. the duration is subtracted
+// from now.
+func ParseAge(s string, now time.Time) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	if s != "" && reDuration.ReplaceAllString(s, "") == "" {
+		d := time.Duration(0)
+		for _, m := range reDuration.FindAllStringSubmatch(s, -1) {
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			d += time.Duration(n) * durationUnits[m[2]]
+		}
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither a duration nor a date", s)
+}
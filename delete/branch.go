@@ -0,0 +1,84 @@
+package delete
+
+import (
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// branchIndex answers whether the target commitish of the release belongs to
+// any branch. the branches are fetched once, and the results are cached for
+// each target so the releases that share the target do not call the API.
+type branchIndex struct {
+	ghc      *github.Client
+	loaded   bool
+	branches []*github.Branch
+	names    map[string]bool
+	heads    map[string]bool
+	results  map[string]bool
+}
+
+func newBranchIndex(ghc *github.Client) *branchIndex {
+	return &branchIndex{
+		ghc:     ghc,
+		names:   map[string]bool{},
+		heads:   map[string]bool{},
+		results: map[string]bool{},
+	}
+}
+
+func (idx *branchIndex) load() error {
+	if idx.loaded {
+		return nil
+	}
+
+	if err := idx.ghc.FetchBranch(1, 100, func(b *github.Branch, _ int) error {
+		idx.branches = append(idx.branches, b)
+		idx.names[b.Name] = true
+		idx.heads[b.Commit.SHA] = true
+		return nil
+	}); err != nil {
+		return err
+	}
+	idx.loaded = true
+	log.Debug("%d branches are loaded", len(idx.branches))
+	return nil
+}
+
+// contains returns true if any branch contains the commit. it stops comparing
+// at the first branch that contains the commit.
+func (idx *branchIndex) contains(sha string) (bool, error) {
+	if idx.heads[sha] {
+		return true, nil
+	}
+
+	for _, b := range idx.branches {
+		cmp, err := idx.ghc.CompareTwoCommit(b.Name, sha, 1, 1)
+		if err != nil {
+			return false, err
+		} else if cmp != nil && (cmp.Status == "behind" || cmp.Status == "identical") {
+			log.Debug("commit %s is contained in the branch %q", sha, b.Name)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isBranched returns true if the target commitish is a branch name, or the
+// commit that is contained in any branch.
+func (idx *branchIndex) isBranched(targetCommitish string) (bool, error) {
+	if v, ok := idx.results[targetCommitish]; ok {
+		return v, nil
+	} else if err := idx.load(); err != nil {
+		return false, err
+	}
+
+	v := idx.names[targetCommitish]
+	if !v && IsHexSHA1(targetCommitish) {
+		var err error
+		if v, err = idx.contains(targetCommitish); err != nil {
+			return false, err
+		}
+	}
+	idx.results[targetCommitish] = v
+	return v, nil
+}
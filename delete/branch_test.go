@@ -0,0 +1,111 @@
+package delete
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sha1 = strings.Repeat("1", 40)
+	sha2 = strings.Repeat("2", 40)
+	sha3 = strings.Repeat("3", 40)
+)
+
+func Test_branchIndex(t *testing.T) {
+	requests := []string{}
+	deleted := []string{}
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo")
+		if r.Method == "DELETE" {
+			deleted = append(deleted, path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		requests = append(requests, path)
+
+		switch {
+		case path == "/releases":
+			fmt.Fprintf(w, `[
+				{"id": 1, "tag_name": "v1", "target_commitish": "main"},
+				{"id": 2, "tag_name": "v2", "target_commitish": "gone"},
+				{"id": 3, "tag_name": "v3", "target_commitish": %q},
+				{"id": 4, "tag_name": "v4", "target_commitish": %q},
+				{"id": 5, "tag_name": "v5", "target_commitish": %q},
+				{"id": 6, "tag_name": "v6", "target_commitish": %q}
+			]`, sha1, sha2, sha2, sha3)
+
+		case path == "/branches":
+			fmt.Fprintf(w, `[
+				{"name": "main", "commit": {"sha": %q}},
+				{"name": "dev", "commit": {"sha": "dev"}},
+				{"name": "feat", "commit": {"sha": "feat"}}
+			]`, sha1)
+
+		case path == "/branches/main" || path == "/branches/dev" || path == "/branches/feat":
+			fmt.Fprintf(w, `{"name": %q}`, strings.TrimPrefix(path, "/branches/"))
+
+		case strings.HasPrefix(path, "/compare/"):
+			status := "diverged"
+			switch strings.TrimPrefix(path, "/compare/") {
+			case "main..." + sha1:
+				status = "identical"
+			case "dev..." + sha2:
+				status = "behind"
+			case "feat..." + sha3:
+				status = "ahead"
+			}
+			fmt.Fprintf(w, `{"status": %q}`, status)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	// test that returns the same result as looking up the branches per target
+	idx := newBranchIndex(ghc)
+	for _, target := range []string{"main", "gone", sha1, sha2, sha3} {
+		var branches []*github.Branch
+		if IsHexSHA1(target) {
+			list, err := ghc.ListBranchesOfCommit(target, 20, 20)
+			assert.NoError(t, err)
+			branches = list
+		} else if b, err := ghc.GetBranch(target); assert.NoError(t, err) && b != nil {
+			branches = append(branches, b)
+		}
+
+		ok, err := idx.isBranched(target)
+		assert.NoError(t, err)
+		assert.Equal(t, len(branches) > 0, ok, target)
+	}
+
+	// test that fetch the branches once, and stop comparing at the first
+	// branch that contains the commit, and use the cached result
+	requests = []string{}
+	list, err := UnbranchedReleases(ghc, &UnbranchedReleasesOption{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/releases",
+		"/branches",
+		"/compare/main..." + sha2,
+		"/compare/dev..." + sha2,
+		"/compare/main..." + sha3,
+		"/compare/dev..." + sha3,
+		"/compare/feat..." + sha3,
+	}, requests)
+	ids := []int{}
+	for _, v := range list {
+		ids = append(ids, v.ID)
+	}
+	assert.Equal(t, []int{2, 6}, ids)
+	assert.Equal(t, []string{
+		"/releases/2", "/git/refs/tags/v2",
+		"/releases/6", "/git/refs/tags/v6",
+	}, deleted)
+}
@@ -2,11 +2,12 @@ package delete
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
+	"github.com/mah0x211/github-release-admin/util"
 )
 
 func deleteTag(ghc *github.Client, v *github.Release, dryrun bool) error {
@@ -41,17 +42,6 @@ func IsHexSHA1(s string) bool {
 	return len(s) == 40 && reHex.MatchString(s)
 }
 
-func getBranches(ghc *github.Client, targetCommitish string) ([]*github.Branch, error) {
-	if IsHexSHA1(targetCommitish) {
-		return ghc.ListBranchesOfCommit(targetCommitish, 20, 20)
-	} else if v, err := ghc.GetBranch(targetCommitish); err != nil {
-		return nil, err
-	} else if v != nil {
-		return []*github.Branch{v}, nil
-	}
-	return nil, nil
-}
-
 type UnbranchedReleasesOption struct {
 	ItemsPerPage int
 	DryRun       bool
@@ -59,10 +49,11 @@ type UnbranchedReleasesOption struct {
 
 func UnbranchedReleases(ghc *github.Client, o *UnbranchedReleasesOption) ([]*github.Release, error) {
 	list := []*github.Release{}
+	idx := newBranchIndex(ghc)
 	if err := ghc.FetchRelease(1, o.ItemsPerPage, func(v *github.Release, _ int) error {
-		if list, err := getBranches(ghc, v.TargetCommitish); err != nil {
+		if ok, err := idx.isBranched(v.TargetCommitish); err != nil {
 			return err
-		} else if len(list) > 0 {
+		} else if ok {
 			log.Debug("ignore the release associated with the branch: %d", v.ID)
 			return nil
 		} else if err = deleteRelease(ghc, v, o.DryRun); err != nil {
@@ -78,6 +69,7 @@ func UnbranchedReleases(ghc *github.Client, o *UnbranchedReleasesOption) ([]*git
 }
 
 type DraftReleasesOption struct {
+	Age
 	ItemsPerPage int
 	DryRun       bool
 	Branch       string
@@ -92,6 +84,8 @@ func DraftReleases(ghc *github.Client, o *DraftReleasesOption) ([]*github.Releas
 		} else if o.Branch != "" && v.TargetCommitish != o.Branch {
 			log.Debug("ignore release that branch does not matched to %q: %d", o.Branch, v.ID)
 			return nil
+		} else if !o.match(v) {
+			return nil
 		} else if err := deleteRelease(ghc, v, o.DryRun); err != nil {
 			return err
 		}
@@ -105,6 +99,7 @@ func DraftReleases(ghc *github.Client, o *DraftReleasesOption) ([]*github.Releas
 }
 
 type PreReleasesOption struct {
+	Age
 	ItemsPerPage int
 	DryRun       bool
 	Branch       string
@@ -119,6 +114,8 @@ func PreReleases(ghc *github.Client, o *PreReleasesOption) ([]*github.Release, e
 		} else if o.Branch != "" && v.TargetCommitish != o.Branch {
 			log.Debug("ignore release that branch does not matched to %q: %d", o.Branch, v.ID)
 			return nil
+		} else if !o.match(v) {
+			return nil
 		} else if err := deleteRelease(ghc, v, o.DryRun); err != nil {
 			return err
 		}
@@ -132,6 +129,7 @@ func PreReleases(ghc *github.Client, o *PreReleasesOption) ([]*github.Release, e
 }
 
 type ReleasesByTagNameOption struct {
+	Age
 	ItemsPerPage    int
 	TagName         string
 	TargetCommitish string
@@ -140,6 +138,9 @@ type ReleasesByTagNameOption struct {
 	Draft           bool
 	PreRelease      bool
 	DryRun          bool
+	// Semver deletes only the releases that tag is a semantic version that
+	// satisfies the constraint. the TagName can be omitted.
+	Semver *semver.Constraint
 }
 
 func isDeletionTarget(v *github.Release, o *ReleasesByTagNameOption, re *regexp.Regexp) bool {
@@ -152,18 +153,21 @@ func isDeletionTarget(v *github.Release, o *ReleasesByTagNameOption, re *regexp.
 	} else if o.TargetCommitish != "" && v.TargetCommitish != o.TargetCommitish {
 		log.Debug("ignore release that commitish does not matched to %q: %d", o.TargetCommitish, v.ID)
 		return false
-	} else if re != nil && !re.MatchString(v.TagName) {
+	} else if !util.MatchName(re, o.TagName, v.TagName) {
 		log.Debug("ignore release that tag-name does not matched to %q: %d", o.TagName, v.ID)
 		return false
+	} else if o.Semver != nil && !o.Semver.Match(v.TagName) {
+		log.Debug("ignore release that tag-name does not satisfy %q: %d", o.Semver, v.ID)
+		return false
 	}
-	return true
+	return o.match(v)
 }
 
 func ReleasesByTagName(ghc *github.Client, o *ReleasesByTagNameOption) ([]*github.Release, error) {
 	list := []*github.Release{}
 
-	if !o.AsRegex {
-		v, err := ghc.GetReleaseByTagName(o.TagName)
+	if !o.AsRegex && o.Semver == nil {
+		v, err := ghc.FindReleaseByTagName(o.TagName)
 		if err != nil {
 			return list, err
 		} else if v == nil || !isDeletionTarget(v, o, nil) {
@@ -174,17 +178,9 @@ func ReleasesByTagName(ghc *github.Client, o *ReleasesByTagNameOption) ([]*githu
 		return append(list, v), deleteTag(ghc, v, o.DryRun)
 	}
 
-	var re *regexp.Regexp
-	var err error
-	if o.AsPosix {
-		re, err = regexp.CompilePOSIX(o.TagName)
-	} else {
-		re, err = regexp.Compile(o.TagName)
-	}
+	re, err := util.CompileName(o.TagName, o.AsRegex, o.AsPosix)
 	if err != nil {
-		return list, fmt.Errorf(
-			"%q cannot be compiled as regular expression: %w", o.TagName, err,
-		)
+		return list, err
 	}
 
 	if err = ghc.FetchRelease(1, o.ItemsPerPage, func(v *github.Release, _ int) error {
@@ -0,0 +1,192 @@
+package delete
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
+)
+
+// GroupBy specifies how the releases are grouped to keep the newest releases
+// in each group.
+type GroupBy string
+
+const (
+	GroupByNone   GroupBy = "none"
+	GroupByBranch GroupBy = "branch"
+	GroupByMajor  GroupBy = "major"
+	GroupByMinor  GroupBy = "minor"
+)
+
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch v := GroupBy(s); v {
+	case GroupByNone, GroupByBranch, GroupByMajor, GroupByMinor:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid group-by %q", s)
+}
+
+// OrderBy specifies how the releases are ordered from newest to oldest.
+type OrderBy string
+
+const (
+	// OrderByCreated orders the releases by the created_at.
+	OrderByCreated OrderBy = "created"
+	// OrderBySemver orders the releases by the semantic version of the tag.
+	// the releases that tag is not a semantic version are kept.
+	OrderBySemver OrderBy = "semver"
+)
+
+func ParseOrderBy(s string) (OrderBy, error) {
+	switch v := OrderBy(s); v {
+	case OrderByCreated, OrderBySemver:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid order-by %q", s)
+}
+
+type PruneReleasesOption struct {
+	// Age limits the releases to delete. the releases that do not match are
+	// kept even if they are not the newest releases.
+	Age
+	ItemsPerPage int
+	DryRun       bool
+	Branch       string
+	Draft        bool
+	PreRelease   bool
+	// Keep is the number of the newest releases to keep in each group.
+	Keep    int
+	GroupBy GroupBy
+	OrderBy OrderBy
+}
+
+type PruneResult struct {
+	Kept    []*github.Release `json:"kept"`
+	Deleted []*github.Release `json:"deleted"`
+}
+
+type pruneTarget struct {
+	release *github.Release
+	version *semver.Version
+}
+
+func isPruneTarget(v *github.Release, o *PruneReleasesOption) bool {
+	if o.Draft && !v.Draft {
+		log.Debug("ignore non-draft release: %d", v.ID)
+		return false
+	} else if o.PreRelease && !v.PreRelease {
+		log.Debug("ignore non-prerelease: %d", v.ID)
+		return false
+	} else if o.Branch != "" && v.TargetCommitish != o.Branch {
+		log.Debug("ignore release that branch does not matched to %q: %d", o.Branch, v.ID)
+		return false
+	}
+	return true
+}
+
+func groupKey(t *pruneTarget, groupBy GroupBy) string {
+	switch groupBy {
+	case GroupByBranch:
+		return t.release.TargetCommitish
+	case GroupByMajor:
+		return fmt.Sprintf("v%d", t.version.Major)
+	case GroupByMinor:
+		return fmt.Sprintf("v%d.%d", t.version.Major, t.version.Minor)
+	}
+	return ""
+}
+
+// isNewer returns true if a is newer than b.
+func isNewer(a, b *pruneTarget, orderBy OrderBy) bool {
+	if orderBy == OrderBySemver {
+		if c := a.version.Compare(b.version); c != 0 {
+			return c > 0
+		}
+	} else if x, y := a.release.CreatedAt, b.release.CreatedAt; x == nil || y == nil {
+		if x != nil || y != nil {
+			return x != nil
+		}
+	} else if !x.Equal(*y) {
+		return x.After(*y)
+	}
+	return a.release.ID > b.release.ID
+}
+
+// PruneReleases deletes the releases except for the newest o.Keep releases in
+// each group. it returns both the kept and the deleted releases.
+func PruneReleases(ghc *github.Client, o *PruneReleasesOption) (*PruneResult, error) {
+	if o.Keep < 0 {
+		return nil, fmt.Errorf("the number of the releases to keep must not be negative")
+	}
+	groupBy := o.GroupBy
+	if groupBy == "" {
+		groupBy = GroupByNone
+	}
+	orderBy := o.OrderBy
+	if orderBy == "" {
+		orderBy = OrderByCreated
+	}
+	needVersion := orderBy == OrderBySemver || groupBy == GroupByMajor || groupBy == GroupByMinor
+
+	res := &PruneResult{
+		Kept:    []*github.Release{},
+		Deleted: []*github.Release{},
+	}
+	groups := map[string][]*pruneTarget{}
+	keys := []string{}
+	// list all releases before deleting not to change the pages
+	if err := ghc.FetchRelease(1, o.ItemsPerPage, func(v *github.Release, _ int) error {
+		if !isPruneTarget(v, o) {
+			return nil
+		}
+
+		t := &pruneTarget{release: v}
+		if needVersion {
+			ver, err := semver.Parse(v.TagName)
+			if err != nil {
+				log.Debug("keep release that tag is not a semantic version: %d", v.ID)
+				res.Kept = append(res.Kept, v)
+				return nil
+			}
+			t.version = ver
+		}
+
+		k := groupKey(t, groupBy)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], t)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	targets := []*github.Release{}
+	for _, k := range keys {
+		list := groups[k]
+		sort.SliceStable(list, func(i, j int) bool {
+			return isNewer(list[i], list[j], orderBy)
+		})
+		for i, t := range list {
+			if i < o.Keep || !o.match(t.release) {
+				res.Kept = append(res.Kept, t.release)
+			} else {
+				targets = append(targets, t.release)
+			}
+		}
+	}
+
+	for _, v := range targets {
+		if err := deleteRelease(ghc, v, o.DryRun); err != nil {
+			return res, err
+		}
+		res.Deleted = append(res.Deleted, v)
+		if err := deleteTag(ghc, v, o.DryRun); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package delete
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func releaseIDs(list []*github.Release) []int {
+	ids := []int{}
+	for _, v := range list {
+		ids = append(ids, v.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func Test_PruneReleases(t *testing.T) {
+	deleted := []string{}
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/releases":
+			fmt.Fprint(w, `[
+				{"id": 1, "tag_name": "v1.0.0", "target_commitish": "main", "created_at": "2021-01-01T00:00:00Z"},
+				{"id": 2, "tag_name": "v1.1.0", "target_commitish": "main", "created_at": "2021-02-01T00:00:00Z"},
+				{"id": 3, "tag_name": "v2.0.0-rc.1", "target_commitish": "next", "created_at": "2021-03-01T00:00:00Z", "prerelease": true},
+				{"id": 4, "tag_name": "v1.2.0", "target_commitish": "main", "created_at": "2021-02-01T00:00:00Z", "draft": true},
+				{"id": 5, "tag_name": "nightly", "target_commitish": "next", "created_at": "2021-04-01T00:00:00Z", "prerelease": true},
+				{"id": 6, "tag_name": "v2.0.0", "target_commitish": "next", "created_at": "2020-12-01T00:00:00Z"}
+			]`)
+
+		case r.Method == "DELETE":
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/"))
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	for _, v := range []struct {
+		name    string
+		option  *PruneReleasesOption
+		kept    []int
+		deleted []int
+	}{
+		{
+			name:    "keep the newest releases by created_at, and the newer id on tie",
+			option:  &PruneReleasesOption{Keep: 3, DryRun: true},
+			kept:    []int{3, 4, 5},
+			deleted: []int{1, 2, 6},
+		},
+		{
+			name:    "keep the newest releases in each branch",
+			option:  &PruneReleasesOption{Keep: 1, GroupBy: GroupByBranch, DryRun: true},
+			kept:    []int{4, 5},
+			deleted: []int{1, 2, 3, 6},
+		},
+		{
+			name:    "keep the newest releases in each major version by semver",
+			option:  &PruneReleasesOption{Keep: 1, GroupBy: GroupByMajor, OrderBy: OrderBySemver, DryRun: true},
+			kept:    []int{4, 5, 6},
+			deleted: []int{1, 2, 3},
+		},
+		{
+			name:    "keep the newest releases in each minor version by created_at",
+			option:  &PruneReleasesOption{Keep: 1, GroupBy: GroupByMinor, DryRun: true},
+			kept:    []int{1, 2, 3, 4, 5},
+			deleted: []int{6},
+		},
+		{
+			name:    "prune only the drafts",
+			option:  &PruneReleasesOption{Keep: 0, Draft: true, DryRun: true},
+			kept:    []int{},
+			deleted: []int{4},
+		},
+		{
+			name:    "prune only the prereleases",
+			option:  &PruneReleasesOption{Keep: 1, PreRelease: true, DryRun: true},
+			kept:    []int{5},
+			deleted: []int{3},
+		},
+		{
+			name:    "delete all releases with keep=0",
+			option:  &PruneReleasesOption{Keep: 0, Branch: "main", DryRun: true},
+			kept:    []int{},
+			deleted: []int{1, 2, 4},
+		},
+	} {
+		res, err := PruneReleases(ghc, v.option)
+		assert.NoError(t, err, v.name)
+		assert.Equal(t, v.kept, releaseIDs(res.Kept), v.name)
+		assert.Equal(t, v.deleted, releaseIDs(res.Deleted), v.name)
+	}
+	assert.Empty(t, deleted)
+
+	// test that delete the release and its tag
+	res, err := PruneReleases(ghc, &PruneReleasesOption{Keep: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{6}, releaseIDs(res.Deleted))
+	assert.Equal(t, []string{"releases/6", "git/refs/tags/v2.0.0"}, deleted)
+
+	// test that returns error if the keep is negative
+	_, err = PruneReleases(ghc, &PruneReleasesOption{Keep: -1})
+	assert.Error(t, err)
+}
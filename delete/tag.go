@@ -0,0 +1,70 @@
+package delete
+
+import (
+	"regexp"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/util"
+)
+
+type OrphanTagsOption struct {
+	ItemsPerPage int
+	DryRun       bool
+	// TagName deletes only the tags that match the name. if it is empty,
+	// all the orphaned tags are deleted.
+	TagName string
+	AsRegex bool
+	AsPosix bool
+}
+
+func isOrphanTag(v *github.Tag, o *OrphanTagsOption, re *regexp.Regexp, released map[string]bool) bool {
+	if released[v.Name] {
+		log.Debug("ignore tag that has the release: %s", v.Name)
+		return false
+	} else if !util.MatchName(re, o.TagName, v.Name) {
+		log.Debug("ignore tag that does not matched to %q: %s", o.TagName, v.Name)
+		return false
+	}
+	return true
+}
+
+// OrphanTags deletes the tags that are not referenced by any release,
+// including the drafts.
+func OrphanTags(ghc *github.Client, o *OrphanTagsOption) ([]*github.Tag, error) {
+	list := []*github.Tag{}
+	re, err := util.CompileName(o.TagName, o.AsRegex, o.AsPosix)
+	if err != nil {
+		return list, err
+	}
+
+	released := map[string]bool{}
+	if err = ghc.FetchRelease(1, o.ItemsPerPage, func(v *github.Release, _ int) error {
+		released[v.TagName] = true
+		return nil
+	}); err != nil {
+		return list, err
+	}
+
+	// list all tags before deleting not to change the pages
+	targets := []*github.Tag{}
+	if err = ghc.FetchTag(1, o.ItemsPerPage, func(v *github.Tag, _ int) error {
+		if isOrphanTag(v, o, re, released) {
+			targets = append(targets, v)
+		}
+		return nil
+	}); err != nil {
+		return list, err
+	}
+
+	for _, v := range targets {
+		log.Debug("delete tag %s", v.Name)
+		if !o.DryRun {
+			if err = ghc.DeleteTag(v.Name); err != nil {
+				return list, err
+			}
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
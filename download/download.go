@@ -1,38 +1,288 @@
 package download
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/mah0x211/github-release-admin/checksum"
+	"github.com/mah0x211/github-release-admin/extract"
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
 )
 
 type Option struct {
 	SaveAs string
 	DryRun bool
+	// Verify verifies the downloaded asset with the checksum manifest in the
+	// same release.
+	Verify bool
+	// ChecksumFile is the name of the checksum manifest. "<asset>" is
+	// replaced with the name of the asset. (default: DefaultChecksumFiles)
+	ChecksumFile string
+	// SHA256 is the expected hex encoded SHA256 digest of the asset.
+	SHA256 string
+	// AsRegex compiles the name as regular expressions.
+	AsRegex bool
+	// AsPosix compiles the name as POSIX ERE (egrep).
+	AsPosix bool
+	// All downloads all the assets that match the name. if the name is
+	// empty, all the assets of the release are downloaded.
+	All bool
+	// Dirname is the directory to save the assets.
+	Dirname string
+	// Platform selects the asset that best matches the platform from the
+	// assets that match the name.
+	Platform *Platform
+	// Writer, if not nil, receives the content of the asset instead of
+	// saving it to the file.
+	Writer io.Writer
+	// Connections is the number of the concurrent connections to download
+	// an asset in segments.
+	Connections int
+	// Extract extracts the downloaded archives into the ExtractDir, or the
+	// Dirname if not specified.
+	Extract    bool
+	ExtractDir string
+	extract.Option
+	// Semver, if not nil, makes Latest select the release that has the
+	// highest semantic version that satisfies the constraint. the drafts and
+	// the prereleases are ignored.
+	Semver *semver.Constraint
 }
 
-func download(ghc *github.Client, v *github.Asset, o *Option) error {
+// Result is the result of downloading an asset.
+type Result struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	Path string `json:"path"`
+	// Extracted is the pathnames of the files extracted from the asset.
+	Extracted []string `json:"extracted,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// DefaultChecksumFiles are the names of the checksum manifests looked up in
+// order. "<asset>" is replaced with the name of the asset.
+var DefaultChecksumFiles = []string{
+	"<asset>.sha512",
+	"<asset>.sha256",
+	"SHA512SUMS",
+	"SHA256SUMS",
+	"checksums.txt",
+}
+
+// isChecksumFile returns true if the name is one of the DefaultChecksumFiles.
+func isChecksumFile(name string) bool {
+	for _, v := range DefaultChecksumFiles {
+		if i := strings.Index(v, "<asset>"); i < 0 {
+			if name == v {
+				return true
+			}
+		} else if prefix, suffix := v[:i], v[i+len("<asset>"):]; len(name) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type downloader struct {
+	ghc *github.Client
+	v   *github.Release
+	o   *Option
+	// manifests caches the fetched checksum manifests by the asset id
+	manifests map[int]map[string]string
+}
+
+// fetchChecksums downloads the checksum manifest and returns its content.
+func (d *downloader) fetchChecksums(m *github.Asset) (map[string]string, error) {
+	if sums, ok := d.manifests[m.ID]; ok {
+		return sums, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := d.ghc.DownloadAssetTo(m.ID, b, nil); err != nil {
+		return nil, err
+	}
+
+	sums, err := checksum.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	d.manifests[m.ID] = sums
+	return sums, nil
+}
+
+// verifier returns the verifier of the asset. it returns nil if the asset
+// does not need to be verified.
+func (d *downloader) verifier(a *github.Asset) (github.Verifier, error) {
+	o := d.o
+	if o.SHA256 != "" {
+		if algo, err := checksum.AlgorithmOf(o.SHA256); err != nil {
+			return nil, err
+		} else if algo != "sha256" {
+			return nil, fmt.Errorf("invalid sha256 checksum %q", o.SHA256)
+		}
+		return checksum.NewVerifier(o.SHA256)
+	} else if !o.Verify {
+		return nil, nil
+	}
+
+	if isChecksumFile(a.Name) {
+		log.Debug("ignore the checksum manifest that cannot be verified: %s", a.Name)
+		return nil, nil
+	}
+
+	names := DefaultChecksumFiles
+	if o.ChecksumFile != "" {
+		names = []string{o.ChecksumFile}
+	}
+	var notfound error
+	for _, name := range names {
+		name = strings.ReplaceAll(name, "<asset>", a.Name)
+		m := selectAsset(d.v.Assets, name)
+		if m == nil {
+			continue
+		} else if m.ID == a.ID {
+			// the manifest itself cannot be verified
+			return nil, nil
+		}
+
+		log.Debug("verify asset %d with %s", a.ID, m.Name)
+		if o.DryRun {
+			return nil, nil
+		}
+		sums, err := d.fetchChecksums(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the checksum manifest %s: %w", m.Name, err)
+		}
+		if digest, ok := sums[a.Name]; ok {
+			return checksum.NewVerifier(digest)
+		} else if digest, ok := sums[""]; ok && len(sums) == 1 {
+			return checksum.NewVerifier(digest)
+		}
+		// try the next manifest that may list the asset
+		log.Debug("checksum of %s is not found in %s", a.Name, m.Name)
+		notfound = fmt.Errorf("checksum of %s is not found in %s", a.Name, m.Name)
+	}
+
+	if notfound != nil {
+		return nil, notfound
+	}
+	return nil, fmt.Errorf("checksum manifest of %s is not found", a.Name)
+}
+
+func (d *downloader) download(a *github.Asset, saveAs string) error {
 	if log.Verbose {
-		b, err := json.MarshalIndent(v, "", "  ")
+		b, err := json.MarshalIndent(a, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to stringify the asset-info: %w", err)
 		}
-		log.Debug("download asset %d: %s", v.ID, b)
+		log.Debug("download asset %d: %s", a.ID, b)
 	}
 
-	if o.DryRun {
+	vf, err := d.verifier(a)
+	if err != nil {
+		return err
+	} else if d.o.DryRun {
 		return nil
 	}
 
-	saveAs := v.Name
-	if o.SaveAs = strings.TrimSpace(o.SaveAs); o.SaveAs != "" {
-		saveAs = o.SaveAs
+	o := &github.DownloadOption{
+		Verifier:    vf,
+		Connections: d.o.Connections,
+	}
+	if d.o.Writer != nil {
+		return d.ghc.DownloadAssetTo(a.ID, d.o.Writer, o)
+	}
+	return d.ghc.DownloadAsset(a.ID, saveAs, o)
+}
+
+// extract extracts the downloaded archive. the asset that is not an archive is
+// ignored.
+func (d *downloader) extract(pathname string) ([]string, error) {
+	o := d.o
+	if !o.Extract {
+		return nil, nil
+	} else if extract.FormatOf(pathname) == "" {
+		log.Debug("ignore asset that is not an archive: %s", pathname)
+		return nil, nil
 	}
 
-	return ghc.DownloadAsset(v.ID, saveAs)
+	dirname := o.ExtractDir
+	if dirname == "" {
+		dirname = o.Dirname
+	}
+	log.Debug("extract %s into %q", pathname, dirname)
+	if o.DryRun {
+		return nil, nil
+	}
+	return extract.File(pathname, dirname, &o.Option)
+}
+
+func download(ghc *github.Client, v *github.Release, assets []*github.Asset, o *Option) ([]*Result, error) {
+	d := &downloader{
+		ghc:       ghc,
+		v:         v,
+		o:         o,
+		manifests: map[int]map[string]string{},
+	}
+
+	if o.Dirname != "" && !o.DryRun {
+		if err := os.MkdirAll(o.Dirname, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	list := []*Result{}
+	errs := []error{}
+	for _, a := range assets {
+		saveAs := a.Name
+		if o.SaveAs = strings.TrimSpace(o.SaveAs); o.SaveAs != "" {
+			saveAs = o.SaveAs
+		}
+		r := &Result{
+			ID:   a.ID,
+			Name: a.Name,
+			Size: a.Size,
+			Path: filepath.Join(o.Dirname, saveAs),
+		}
+		if o.Writer != nil {
+			r.Path = ""
+		}
+		if err := d.download(a, r.Path); err != nil {
+			if github.IsNotFound(err) {
+				// the asset has been deleted after listing
+				err = assetNotFound(a.Name, err)
+			}
+			log.Errorf("failed to download %s: %v", a.Name, err)
+			r.Error = err.Error()
+			errs = append(errs, err)
+		} else if r.Extracted, err = d.extract(r.Path); err != nil {
+			log.Errorf("failed to extract %s: %v", a.Name, err)
+			r.Error = err.Error()
+			errs = append(errs, err)
+		}
+		list = append(list, r)
+	}
+
+	if len(errs) > 0 {
+		if len(list) == 1 {
+			return list, errs[0]
+		}
+		return list, fmt.Errorf(
+			"failed to download %d of %d assets: %w", len(errs), len(list), errs[0],
+		)
+	}
+	return list, nil
 }
 
 func selectAsset(v []github.Asset, name string) *github.Asset {
@@ -44,48 +294,183 @@ func selectAsset(v []github.Asset, name string) *github.Asset {
 	return nil
 }
 
+// selectAssets returns the assets that match the name. it returns an error if
+// multiple assets match the name but o.All is false.
+func selectAssets(v []github.Asset, name string, o *Option) ([]*github.Asset, error) {
+	var re *regexp.Regexp
+	var err error
+	if o.AsPosix {
+		re, err = regexp.CompilePOSIX(name)
+	} else if o.AsRegex {
+		re, err = regexp.Compile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%q cannot be compiled as regular expression: %w", name, err,
+		)
+	}
+
+	list := []*github.Asset{}
+	for i := range v {
+		a := &v[i]
+		if (name == "" && (o.All || o.Platform != nil)) ||
+			(re == nil && a.Name == name) ||
+			(re != nil && re.MatchString(a.Name)) {
+			list = append(list, a)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, assetNotFound(name, nil)
+	} else if o.Platform != nil {
+		a, err := SelectPlatformAsset(list, o.Platform)
+		if err != nil {
+			return nil, err
+		}
+		return []*github.Asset{a}, nil
+	} else if len(list) > 1 && !o.All {
+		names := make([]string, 0, len(list))
+		for _, a := range list {
+			names = append(names, a.Name)
+		}
+		return nil, fmt.Errorf("%q matches multiple assets %q", name, names)
+	} else if len(list) > 1 && o.Writer != nil {
+		return nil, fmt.Errorf("cannot write multiple assets to the writer")
+	} else if len(list) > 1 && o.SaveAs != "" {
+		return nil, fmt.Errorf("cannot save multiple assets as %q", o.SaveAs)
+	} else if len(list) > 1 && o.SHA256 != "" {
+		return nil, fmt.Errorf("cannot verify multiple assets with a SHA256 digest")
+	}
+	return list, nil
+}
+
 var ErrNotFound = fmt.Errorf("not found")
+var ErrReleaseNotFound = fmt.Errorf("release not found")
+var ErrAssetNotFound = fmt.Errorf("asset not found")
 
-func Latest(ghc *github.Client, name string, o *Option) error {
-	var a *github.Asset
+// NotFoundError is the error that the release or the asset is not found. it
+// matches ErrNotFound, and ErrReleaseNotFound or ErrAssetNotFound with
+// errors.Is.
+type NotFoundError struct {
+	// Resource is "release" or "asset".
+	Resource string
+	// Name is the name of the resource.
+	Name string
+	// Err is the underlying error such as *github.APIError.
+	Err error
+}
 
-	if v, err := ghc.GetReleaseLatest(); err != nil {
-		return err
+func (e *NotFoundError) Error() string {
+	s := e.Resource + " not found"
+	if e.Name != "" {
+		s = fmt.Sprintf("%s %q not found", e.Resource, e.Name)
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return true
+	case ErrReleaseNotFound:
+		return e.Resource == "release"
+	case ErrAssetNotFound:
+		return e.Resource == "asset"
+	}
+	return false
+}
+
+func releaseNotFound(name string) error {
+	return &NotFoundError{Resource: "release", Name: name}
+}
+
+func assetNotFound(name string, err error) error {
+	return &NotFoundError{Resource: "asset", Name: name, Err: err}
+}
+
+// latestBySemver returns the release that has the highest semantic version
+// that satisfies the constraint.
+func latestBySemver(ghc *github.Client, c *semver.Constraint) (*github.Release, error) {
+	var latest *github.Release
+	var ver *semver.Version
+	if err := ghc.FetchRelease(1, 0, func(v *github.Release, _ int) error {
+		if v.Draft || v.PreRelease {
+			log.Debug("ignore draft or prerelease: %d", v.ID)
+			return nil
+		}
+		x, err := semver.Parse(v.TagName)
+		if err != nil || !c.Check(x) {
+			log.Debug("ignore release that tag-name does not satisfy %q: %d", c, v.ID)
+			return nil
+		} else if ver == nil || x.Compare(ver) > 0 {
+			latest, ver = v, x
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+func Latest(ghc *github.Client, name string, o *Option) ([]*Result, error) {
+	var assets []*github.Asset
+	var v *github.Release
+	var err error
+
+	if o.Semver != nil {
+		if v, err = latestBySemver(ghc, o.Semver); err != nil {
+			return nil, err
+		} else if v == nil {
+			return nil, releaseNotFound(o.Semver.String())
+		}
+	} else if v, err = ghc.GetReleaseLatest(); err != nil {
+		return nil, err
 	} else if v == nil {
-		return ErrNotFound
-	} else if a = selectAsset(v.Assets, name); a == nil {
-		return ErrNotFound
+		return nil, releaseNotFound("latest")
+	}
+
+	if assets, err = selectAssets(v.Assets, name, o); err != nil {
+		return nil, err
 	}
 
-	return download(ghc, a, o)
+	return download(ghc, v, assets, o)
 }
 
-func ByTagName(ghc *github.Client, tag, targetCommitish, name string, o *Option) error {
-	var a *github.Asset
+func ByTagName(ghc *github.Client, tag, targetCommitish, name string, o *Option) ([]*Result, error) {
+	var assets []*github.Asset
 
-	if v, err := ghc.GetReleaseByTagName(tag); err != nil {
-		return err
+	v, err := ghc.FindReleaseByTagName(tag)
+	if err != nil {
+		return nil, err
 	} else if v == nil {
-		return ErrNotFound
+		return nil, releaseNotFound(tag)
 	} else if targetCommitish != "" && v.TargetCommitish != targetCommitish {
-		return ErrNotFound
-	} else if a = selectAsset(v.Assets, name); a == nil {
-		return ErrNotFound
+		return nil, releaseNotFound(tag + "@" + targetCommitish)
+	} else if assets, err = selectAssets(v.Assets, name, o); err != nil {
+		return nil, err
 	}
 
-	return download(ghc, a, o)
+	return download(ghc, v, assets, o)
 }
 
-func Release(ghc *github.Client, id int, name string, o *Option) error {
-	var a *github.Asset
+func Release(ghc *github.Client, id int, name string, o *Option) ([]*Result, error) {
+	var assets []*github.Asset
 
-	if v, err := ghc.GetRelease(id); err != nil {
-		return err
+	v, err := ghc.GetRelease(id)
+	if err != nil {
+		return nil, err
 	} else if v == nil {
-		return ErrNotFound
-	} else if a = selectAsset(v.Assets, name); a == nil {
-		return ErrNotFound
+		return nil, releaseNotFound(strconv.Itoa(id))
+	} else if assets, err = selectAssets(v.Assets, name, o); err != nil {
+		return nil, err
 	}
 
-	return download(ghc, a, o)
+	return download(ghc, v, assets, o)
 }
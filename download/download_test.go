@@ -0,0 +1,90 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_selectAssets(t *testing.T) {
+	assets := []github.Asset{
+		{ID: 1, Name: "tool-linux-amd64"},
+		{ID: 2, Name: "tool-darwin-amd64"},
+	}
+	sha256 := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	// test that the SHA256 can verify a selected asset
+	list, err := selectAssets(assets, "^tool-linux", &Option{
+		AsRegex: true,
+		SHA256:  sha256,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*github.Asset{&assets[0]}, list)
+
+	// test that returns error if the SHA256 is applied to multiple assets
+	list, err = selectAssets(assets, "", &Option{
+		All:    true,
+		SHA256: sha256,
+	})
+	assert.Nil(t, list)
+	assert.Error(t, err)
+}
+
+func Test_downloader_verifier(t *testing.T) {
+	sha256 := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	sha512 := "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/assets/2":
+			fmt.Fprintf(w, "%s  tool\n", sha512)
+		case "/repos/owner/repo/releases/assets/3":
+			fmt.Fprintf(w, "%s  tool\n%s  other\n", sha256, sha256)
+		case "/repos/owner/repo/releases/assets/6":
+			fmt.Fprintf(w, "%s\n", sha256)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	v := &github.Release{
+		Assets: []github.Asset{
+			{ID: 1, Name: "tool"},
+			{ID: 2, Name: "SHA512SUMS"},
+			{ID: 3, Name: "SHA256SUMS"},
+			{ID: 4, Name: "other"},
+			{ID: 5, Name: "missing"},
+			{ID: 6, Name: "tool.sha256"},
+		},
+	}
+	d := &downloader{
+		ghc:       ghc,
+		v:         v,
+		o:         &Option{Verify: true},
+		manifests: map[int]map[string]string{},
+	}
+
+	// test that verify the asset with the first manifest that lists it, and
+	// try the next manifest if SHA512SUMS does not list it
+	for _, a := range []github.Asset{v.Assets[0], v.Assets[3]} {
+		vf, err := d.verifier(&a)
+		assert.NoError(t, err, a.Name)
+		_, _ = vf.Write([]byte("hello"))
+		assert.NoError(t, vf.Verify(), a.Name)
+	}
+
+	// test that the manifests are not verified
+	for _, a := range []github.Asset{v.Assets[1], v.Assets[2], v.Assets[5]} {
+		vf, err := d.verifier(&a)
+		assert.NoError(t, err, a.Name)
+		assert.Nil(t, vf, a.Name)
+	}
+
+	// test that returns error if no manifest lists the asset
+	_, err := d.verifier(&v.Assets[4])
+	assert.EqualError(t, err, "checksum of missing is not found in SHA256SUMS")
+}
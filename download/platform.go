@@ -0,0 +1,200 @@
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// Platform is the pair of the GOOS and GOARCH.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+func (p *Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// ParsePlatform parses the string in the format "<goos>/<goarch>". "auto"
+// returns the platform of the running program.
+func ParsePlatform(s string) (*Platform, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "auto" {
+		return &Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}, nil
+	}
+
+	arr := strings.Split(s, "/")
+	if len(arr) != 2 || arr[0] == "" || arr[1] == "" {
+		return nil, fmt.Errorf("invalid platform %q", s)
+	} else if _, ok := osAliases[arr[0]]; !ok {
+		return nil, fmt.Errorf("unsupported os %q", arr[0])
+	} else if _, ok := archAliases[arr[1]]; !ok {
+		return nil, fmt.Errorf("unsupported architecture %q", arr[1])
+	}
+	return &Platform{OS: arr[0], Arch: arr[1]}, nil
+}
+
+// osAliases are the names of GOOS used in the asset names.
+var osAliases = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "osx", "mac", "apple"},
+	"windows": {"windows", "win64", "win32", "win"},
+	"freebsd": {"freebsd"},
+	"openbsd": {"openbsd"},
+	"netbsd":  {"netbsd"},
+}
+
+// archAliases are the names of GOARCH used in the asset names.
+var archAliases = map[string][]string{
+	"amd64":   {"amd64", "x86_64", "x86-64", "x64"},
+	"arm64":   {"arm64", "aarch64"},
+	"386":     {"386", "i386", "i686", "x86", "32bit"},
+	"arm":     {"armv7", "armv6", "armhf", "arm"},
+	"ppc64le": {"ppc64le"},
+	"s390x":   {"s390x"},
+	"riscv64": {"riscv64"},
+}
+
+// universalArches are the names of the asset that runs on any architecture.
+var universalArches = []string{"universal", "all"}
+
+// extScores are the scores of the file extensions. the assets that have
+// other extensions (e.g. checksums, signatures, packages) are ignored.
+var extScores = map[string]int{
+	".tar.gz": 4,
+	".tgz":    4,
+	".tar":    3,
+	".zip":    2,
+	".gz":     1,
+	".exe":    1,
+	"":        1,
+}
+
+var reToken = regexp.MustCompile(`[a-z0-9]+(?:[_-](?:64|86))?`)
+
+// tokenize splits the name into the lowercase words. "x86_64" and "x86-64"
+// are kept as a word.
+func tokenize(name string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range reToken.FindAllString(strings.ToLower(name), -1) {
+		words[w] = true
+		if i := strings.IndexAny(w, "_-"); i > 0 && w[:i] != "x86" {
+			// e.g. "linux_64" is "linux" and "64"
+			words[w[:i]] = true
+			words[w[i+1:]] = true
+		}
+	}
+	return words
+}
+
+// lookup returns the key whose aliases are contained in the words.
+func lookup(words map[string]bool, aliases map[string][]string) string {
+	keys := make([]string, 0, len(aliases))
+	for k := range aliases {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range aliases[k] {
+			if words[v] {
+				return k
+			}
+		}
+	}
+	return ""
+}
+
+var reExt = regexp.MustCompile(`\.[a-z][a-z0-9]{0,7}$`)
+
+func extOf(name string) string {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip", ".gz", ".exe"} {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	// the dot in the version string is not an extension (e.g. v1.2.3)
+	return reExt.FindString(name)
+}
+
+// scorePlatform returns the score of the asset name for the platform. it
+// returns 0 if the asset is not for the platform.
+func scorePlatform(name string, p *Platform) int {
+	ext := extOf(name)
+	extScore, ok := extScores[ext]
+	if !ok {
+		return 0
+	} else if ext == ".exe" && p.OS != "windows" {
+		return 0
+	}
+
+	words := tokenize(strings.TrimSuffix(strings.ToLower(name), ext))
+	if lookup(words, osAliases) != p.OS {
+		return 0
+	}
+
+	score := 100 + extScore
+	switch arch := lookup(words, archAliases); arch {
+	case p.Arch:
+		score += 50
+	case "":
+		for _, v := range universalArches {
+			if words[v] {
+				score += 10
+				break
+			}
+		}
+	default:
+		return 0
+	}
+
+	if p.OS == "windows" && (ext == ".zip" || ext == ".exe") {
+		// prefer the formats of windows
+		score += 4
+	}
+	return score
+}
+
+// SelectPlatformAsset returns the asset that best matches the platform. it
+// returns an error if no asset matches or the best match is ambiguous.
+func SelectPlatformAsset(v []*github.Asset, p *Platform) (*github.Asset, error) {
+	type candidate struct {
+		score int
+		asset *github.Asset
+	}
+
+	list := []candidate{}
+	for _, a := range v {
+		if score := scorePlatform(a.Name, p); score > 0 {
+			log.Debug("asset %q matches the platform %s: score %d", a.Name, p, score)
+			list = append(list, candidate{score: score, asset: a})
+		}
+	}
+	if len(list) == 0 {
+		return nil, assetNotFound("", fmt.Errorf("no asset matches the platform %s", p))
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].asset.Name < list[j].asset.Name
+	})
+	if len(list) > 1 && list[0].score == list[1].score {
+		names := []string{}
+		for _, c := range list {
+			if c.score == list[0].score {
+				names = append(names, c.asset.Name)
+			}
+		}
+		return nil, fmt.Errorf("multiple assets match the platform %s: %q", p, names)
+	}
+	return list[0].asset, nil
+}
@@ -0,0 +1,88 @@
+package download
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParsePlatform(t *testing.T) {
+	// test that returns the platform of the running program
+	p, err := ParsePlatform("auto")
+	assert.NoError(t, err)
+	assert.Equal(t, &Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}, p)
+
+	// test that parse the goos/goarch
+	p, err = ParsePlatform("Linux/ARM64")
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/arm64", p.String())
+
+	// test that returns error
+	for _, v := range []string{"", "linux", "linux/", "/amd64", "plan9/amd64", "linux/mips", "linux/amd64/v2"} {
+		p, err = ParsePlatform(v)
+		assert.Nil(t, p)
+		assert.Error(t, err)
+	}
+}
+
+func newAssets(names ...string) []*github.Asset {
+	list := []*github.Asset{}
+	for i, name := range names {
+		list = append(list, &github.Asset{ID: i + 1, Name: name})
+	}
+	return list
+}
+
+func Test_SelectPlatformAsset(t *testing.T) {
+	assets := newAssets(
+		"tool_1.2.3_checksums.txt",
+		"tool-1.2.3-linux-amd64.tar.gz",
+		"tool-1.2.3-linux-amd64.tar.gz.sha256",
+		"tool-1.2.3-linux-arm64.tar.gz",
+		"tool_Darwin_x86_64.tgz",
+		"tool_Darwin_arm64.zip",
+		"tool-macos-universal",
+		"tool-windows-x64.exe",
+		"tool-windows-x64.zip",
+		"tool-windows-386.zip",
+	)
+
+	// test that select the asset by the aliases of goos and goarch
+	for p, name := range map[string]string{
+		"linux/amd64":   "tool-1.2.3-linux-amd64.tar.gz",
+		"linux/arm64":   "tool-1.2.3-linux-arm64.tar.gz",
+		"darwin/amd64":  "tool_Darwin_x86_64.tgz",
+		"darwin/arm64":  "tool_Darwin_arm64.zip",
+		"windows/amd64": "tool-windows-x64.zip",
+		"windows/386":   "tool-windows-386.zip",
+	} {
+		pf, err := ParsePlatform(p)
+		assert.NoError(t, err)
+		a, err := SelectPlatformAsset(assets, pf)
+		assert.NoError(t, err, p)
+		assert.Equal(t, name, a.Name, p)
+	}
+
+	// test that select the universal asset if no asset matches the goarch
+	a, err := SelectPlatformAsset(assets, &Platform{OS: "darwin", Arch: "386"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tool-macos-universal", a.Name)
+
+	// test that returns ErrNotFound if no asset matches the platform
+	a, err = SelectPlatformAsset(assets, &Platform{OS: "freebsd", Arch: "amd64"})
+	assert.Nil(t, a)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.True(t, errors.Is(err, ErrAssetNotFound))
+
+	// test that returns error with the candidates if the best match is
+	// ambiguous
+	a, err = SelectPlatformAsset(newAssets(
+		"tool-linux-amd64.tar.gz",
+		"tool_Linux_x86_64.tgz",
+	), &Platform{OS: "linux", Arch: "amd64"})
+	assert.Nil(t, a)
+	assert.EqualError(t, err, `multiple assets match the platform linux/amd64: ["tool-linux-amd64.tar.gz" "tool_Linux_x86_64.tgz"]`)
+}
@@ -0,0 +1,111 @@
+package edit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+type Option struct {
+	github.UpdateReleaseParams
+	DryRun bool
+}
+
+var ErrNotFound = fmt.Errorf("not found")
+var ErrNoChanges = fmt.Errorf("no changes specified")
+
+// preview returns a copy of the release with the changes applied.
+func preview(v *github.Release, o *Option) *github.Release {
+	r := *v
+	if o.TagName != nil {
+		r.TagName = *o.TagName
+	}
+	if o.TargetCommitish != nil {
+		r.TargetCommitish = *o.TargetCommitish
+	}
+	if o.Name != nil {
+		r.Name = *o.Name
+	}
+	if o.Body != nil {
+		r.Body = *o.Body
+	}
+	if o.Draft != nil {
+		r.Draft = *o.Draft
+	}
+	if o.PreRelease != nil {
+		r.PreRelease = *o.PreRelease
+	}
+	return &r
+}
+
+func hasChanges(o *Option) bool {
+	return o.TagName != nil || o.TargetCommitish != nil || o.Name != nil ||
+		o.Body != nil || o.Draft != nil || o.PreRelease != nil ||
+		o.MakeLatest != ""
+}
+
+func edit(ghc *github.Client, v *github.Release, o *Option) (*github.Release, error) {
+	if log.Verbose {
+		b, err := json.MarshalIndent(&o.UpdateReleaseParams, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to stringify the changes: %w", err)
+		}
+		log.Debug("edit release %d: %s", v.ID, b)
+	}
+
+	if o.DryRun {
+		return preview(v, o), nil
+	} else if v, err := ghc.UpdateRelease(v.ID, &o.UpdateReleaseParams); err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	} else {
+		return v, nil
+	}
+}
+
+func Latest(ghc *github.Client, o *Option) (*github.Release, error) {
+	if !hasChanges(o) {
+		return nil, ErrNoChanges
+	}
+
+	v, err := ghc.GetReleaseLatest()
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	}
+	return edit(ghc, v, o)
+}
+
+func ByTagName(ghc *github.Client, tag, targetCommitish string, o *Option) (*github.Release, error) {
+	if !hasChanges(o) {
+		return nil, ErrNoChanges
+	}
+
+	v, err := ghc.FindReleaseByTagName(tag)
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	} else if targetCommitish != "" && v.TargetCommitish != targetCommitish {
+		return nil, ErrNotFound
+	}
+	return edit(ghc, v, o)
+}
+
+func Release(ghc *github.Client, id int, o *Option) (*github.Release, error) {
+	if !hasChanges(o) {
+		return nil, ErrNoChanges
+	}
+
+	v, err := ghc.GetRelease(id)
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	}
+	return edit(ghc, v, o)
+}
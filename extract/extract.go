@@ -0,0 +1,323 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+type Format string
+
+const (
+	Tar   Format = "tar"
+	TarGz Format = "tar.gz"
+	Zip   Format = "zip"
+	Gz    Format = "gz"
+)
+
+// FormatOf returns the archive format of the filename. it returns an empty
+// string if the format is not supported.
+func FormatOf(filename string) Format {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return TarGz
+	case strings.HasSuffix(name, ".tar"):
+		return Tar
+	case strings.HasSuffix(name, ".zip"):
+		return Zip
+	case strings.HasSuffix(name, ".gz"):
+		return Gz
+	}
+	return ""
+}
+
+type Option struct {
+	// StripComponents strips the number of leading components from the
+	// pathnames of the members.
+	StripComponents int
+	// Member extracts only the members that match the glob pattern. the
+	// pattern that does not contain "/" is matched against the base name.
+	Member string
+}
+
+var ErrUnsupported = fmt.Errorf("unsupported archive format")
+
+type extractor struct {
+	dirname string
+	o       *Option
+	list    []string
+}
+
+// pathname returns the pathname to extract the member. it returns an empty
+// string if the member should be skipped.
+func (e *extractor) pathname(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("invalid member %q: absolute path", name)
+	}
+
+	parts := []string{}
+	for _, v := range strings.Split(name, "/") {
+		switch v {
+		case "", ".":
+		case "..":
+			return "", fmt.Errorf("invalid member %q: path traversal", name)
+		default:
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) <= e.o.StripComponents {
+		return "", nil
+	}
+	name = strings.Join(parts[e.o.StripComponents:], "/")
+
+	if e.o.Member != "" {
+		target := name
+		if !strings.Contains(e.o.Member, "/") {
+			target = path.Base(name)
+		}
+		if ok, err := path.Match(e.o.Member, target); err != nil {
+			return "", fmt.Errorf("invalid member pattern %q: %w", e.o.Member, err)
+		} else if !ok {
+			return "", nil
+		}
+	}
+
+	return filepath.Join(e.dirname, filepath.FromSlash(name)), nil
+}
+
+// mkdir creates the parent directories of the pathname. it returns an error
+// if any parent is a symlink not to write the file outside the directory.
+func (e *extractor) mkdir(pathname string) error {
+	rel, err := filepath.Rel(e.dirname, filepath.Dir(pathname))
+	if err != nil {
+		return err
+	} else if rel == "." {
+		return nil
+	}
+
+	dir := e.dirname
+	for _, v := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, v)
+		if fi, err := os.Lstat(dir); os.IsNotExist(err) {
+			if err = os.Mkdir(dir, 0755); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("invalid member %q: parent directory is a symlink", pathname)
+		} else if !fi.IsDir() {
+			return fmt.Errorf("invalid member %q: parent is not a directory", pathname)
+		}
+	}
+	return nil
+}
+
+func (e *extractor) writeFile(pathname string, r io.Reader, mode os.FileMode) error {
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+
+	if err := e.mkdir(pathname); err != nil {
+		return err
+	}
+	// remove the existing file not to write through the symlink
+	if err := os.Remove(pathname); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(pathname, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	} else if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	} else if err = f.Close(); err != nil {
+		return err
+	} else if err = os.Chmod(pathname, perm); err != nil {
+		// preserve the executable bits that are masked by the umask
+		return err
+	}
+
+	log.Debug("extract %s", pathname)
+	e.list = append(e.list, pathname)
+	return nil
+}
+
+// symlink creates the symlink that does not point outside the directory.
+// the ".." is allowed only at the beginning of the target, so it never goes
+// up from the other symlinks that have been extracted, such as "s1/..".
+func (e *extractor) symlink(pathname, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("invalid symlink %q: absolute path", target)
+	}
+	leading := true
+	for _, v := range strings.Split(filepath.ToSlash(target), "/") {
+		if v == ".." && !leading {
+			return fmt.Errorf("invalid symlink %q: path traversal", target)
+		} else if v != ".." && v != "." && v != "" {
+			leading = false
+		}
+	}
+	rel, err := filepath.Rel(e.dirname, filepath.Join(filepath.Dir(pathname), target))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid symlink %q: path traversal", target)
+	}
+
+	if err = e.mkdir(pathname); err != nil {
+		return err
+	} else if err = os.Remove(pathname); err != nil && !os.IsNotExist(err) {
+		return err
+	} else if err = os.Symlink(target, pathname); err != nil {
+		return err
+	}
+	log.Debug("extract %s -> %s", pathname, target)
+	e.list = append(e.list, pathname)
+	return nil
+}
+
+func (e *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		pathname, err := e.pathname(hdr.Name)
+		if err != nil {
+			return err
+		} else if pathname == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// directories are created with the files
+		case tar.TypeReg, tar.TypeRegA:
+			if err = e.writeFile(pathname, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = e.symlink(pathname, hdr.Linkname); err != nil {
+				return err
+			}
+		default:
+			log.Debug("ignore unsupported member %q: type %c", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+func (e *extractor) zip(filename string) error {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		pathname, err := e.pathname(f.Name)
+		if err != nil {
+			return err
+		} else if pathname == "" || f.FileInfo().IsDir() {
+			continue
+		} else if f.Mode()&os.ModeSymlink != 0 {
+			log.Debug("ignore unsupported member %q: symlink", f.Name)
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = e.writeFile(pathname, r, f.Mode())
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extractor) gz(filename string, r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	// use the base name without the extension as the member name
+	name := filepath.Base(filename)
+	name = name[:len(name)-len(".gz")]
+	pathname, err := e.pathname(name)
+	if err != nil || pathname == "" {
+		return err
+	}
+	return e.writeFile(pathname, zr, 0)
+}
+
+// File extracts the archive file into the directory and returns the
+// pathnames of the extracted files.
+func File(filename, dirname string, o *Option) ([]string, error) {
+	if o == nil {
+		o = &Option{}
+	}
+	format := FormatOf(filename)
+	if format == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, filepath.Base(filename))
+	}
+
+	dirname, err := filepath.Abs(dirname)
+	if err != nil {
+		return nil, err
+	} else if err = os.MkdirAll(dirname, 0755); err != nil {
+		return nil, err
+	}
+	e := &extractor{
+		dirname: dirname,
+		o:       o,
+		list:    []string{},
+	}
+
+	if format == Zip {
+		err = e.zip(filename)
+	} else {
+		var f *os.File
+		if f, err = os.Open(filename); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		switch format {
+		case Tar:
+			err = e.tar(f)
+		case TarGz:
+			var zr *gzip.Reader
+			if zr, err = gzip.NewReader(f); err == nil {
+				err = e.tar(zr)
+				zr.Close()
+			}
+		case Gz:
+			err = e.gz(filename, f)
+		}
+	}
+	if err != nil {
+		return e.list, fmt.Errorf("failed to extract %s: %w", filepath.Base(filename), err)
+	} else if o.Member != "" && len(e.list) == 0 {
+		return e.list, fmt.Errorf("no member matches %q in %s", o.Member, filepath.Base(filename))
+	}
+	return e.list, nil
+}
@@ -0,0 +1,221 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type member struct {
+	name string
+	body string
+	mode int64
+	link string
+}
+
+func writeTar(t *testing.T, pathname string, gz bool, members ...member) {
+	b := &bytes.Buffer{}
+	tw := tar.NewWriter(b)
+	for _, m := range members {
+		hdr := &tar.Header{
+			Name:     m.name,
+			Mode:     m.mode,
+			Size:     int64(len(m.body)),
+			Typeflag: tar.TypeReg,
+		}
+		if m.link != "" {
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = m.link
+			hdr.Size = 0
+		}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(m.body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	data := b.Bytes()
+	if gz {
+		zb := &bytes.Buffer{}
+		zw := gzip.NewWriter(zb)
+		_, _ = zw.Write(data)
+		assert.NoError(t, zw.Close())
+		data = zb.Bytes()
+	}
+	assert.NoError(t, ioutil.WriteFile(pathname, data, 0644))
+}
+
+func relPaths(t *testing.T, dir string, list []string) []string {
+	res := []string{}
+	for _, v := range list {
+		rel, err := filepath.Rel(dir, v)
+		assert.NoError(t, err)
+		res = append(res, filepath.ToSlash(rel))
+	}
+	sort.Strings(res)
+	return res
+}
+
+func Test_FormatOf(t *testing.T) {
+	for name, format := range map[string]Format{
+		"foo.tar.gz": TarGz,
+		"foo.TGZ":    TarGz,
+		"foo.tar":    Tar,
+		"foo.zip":    Zip,
+		"foo.gz":     Gz,
+		"foo.tar.xz": "",
+		"foo":        "",
+	} {
+		assert.Equal(t, format, FormatOf(name), name)
+	}
+}
+
+func Test_File_tar(t *testing.T) {
+	src := t.TempDir()
+	dir, err := filepath.Abs(t.TempDir())
+	assert.NoError(t, err)
+
+	pathname := filepath.Join(src, "tool.tar.gz")
+	writeTar(t, pathname, true,
+		member{name: "tool-1.0.0/bin/tool", body: "binary", mode: 0755},
+		member{name: "tool-1.0.0/README.md", body: "readme", mode: 0644},
+		member{name: "tool-1.0.0/bin/link", link: "tool"},
+	)
+
+	// test that extract all members with stripping the leading component
+	list, err := File(pathname, dir, &Option{StripComponents: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "bin/link", "bin/tool"}, relPaths(t, dir, list))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "bin/link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", string(b))
+
+	// test that preserve the executable bits
+	fi, err := os.Stat(filepath.Join(dir, "bin/tool"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	// test that extract only the members that match the base name
+	dir = t.TempDir()
+	list, err = File(pathname, dir, &Option{Member: "tool"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool-1.0.0/bin/tool"}, relPaths(t, dir, list))
+
+	// test that returns error if no member matches
+	_, err = File(pathname, dir, &Option{Member: "*/tool"})
+	assert.Error(t, err)
+}
+
+func Test_File_traversal(t *testing.T) {
+	src := t.TempDir()
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+
+	// test that returns error if the member is outside the directory
+	for _, m := range []member{
+		{name: "../evil", body: "evil"},
+		{name: "foo/../../evil", body: "evil"},
+		{name: "/etc/evil", body: "evil"},
+		{name: "link", link: "../evil"},
+		{name: "link", link: "/etc/passwd"},
+	} {
+		pathname := filepath.Join(src, "evil.tar")
+		writeTar(t, pathname, false, m)
+		_, err := File(pathname, dir, nil)
+		assert.Error(t, err, m.name)
+		_, err = os.Lstat(filepath.Join(parent, "evil"))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// test that returns error if the member is written through the symlink
+	pathname := filepath.Join(src, "evil.tar")
+	writeTar(t, pathname, false,
+		member{name: "dir", link: "."},
+		member{name: "dir/link", link: ".."},
+		member{name: "dir/link/evil", body: "evil"},
+	)
+	_, err := File(pathname, dir, nil)
+	assert.Error(t, err)
+	_, err = os.Lstat(filepath.Join(parent, "evil"))
+	assert.True(t, os.IsNotExist(err))
+
+	// test that returns error if the symlink goes up from the other symlink
+	for _, members := range [][]member{
+		{
+			{name: "a/b/s1", link: "../.."},
+			{name: "a/b/s2", link: "s1/.."},
+		},
+		{
+			{name: "a/b/s2", link: "s1/.."},
+			{name: "a/b/s1", link: "../.."},
+		},
+	} {
+		writeTar(t, pathname, false, members...)
+		_, err = File(pathname, t.TempDir(), nil)
+		assert.Error(t, err)
+	}
+
+	// test that the symlink can go up from the beginning
+	writeTar(t, pathname, false,
+		member{name: "a/b/s1", link: "./../../a"},
+		member{name: "a/b/s2", link: "s1/b"},
+	)
+	_, err = File(pathname, t.TempDir(), nil)
+	assert.NoError(t, err)
+}
+
+func Test_File_zip(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+
+	pathname := filepath.Join(src, "tool.zip")
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	fh := &zip.FileHeader{Name: "tool/tool.exe"}
+	fh.SetMode(0755)
+	w, err := zw.CreateHeader(fh)
+	assert.NoError(t, err)
+	_, _ = w.Write([]byte("binary"))
+	_, err = zw.Create("tool/")
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, ioutil.WriteFile(pathname, b.Bytes(), 0644))
+
+	// test that extract the zip archive
+	list, err := File(pathname, dir, &Option{StripComponents: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool.exe"}, relPaths(t, dir, list))
+	fi, err := os.Stat(list[0])
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+}
+
+func Test_File_gz(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+
+	pathname := filepath.Join(src, "tool-linux-amd64.gz")
+	b := &bytes.Buffer{}
+	zw := gzip.NewWriter(b)
+	_, _ = zw.Write([]byte("binary"))
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, ioutil.WriteFile(pathname, b.Bytes(), 0644))
+
+	// test that extract the file named without the extension
+	list, err := File(pathname, dir, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tool-linux-amd64"}, relPaths(t, dir, list))
+
+	// test that returns ErrUnsupported
+	_, err = File(filepath.Join(src, "tool.tar.xz"), dir, nil)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
@@ -0,0 +1,390 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// Verifier receives the downloaded content and verifies it before the file is
+// moved into place.
+type Verifier interface {
+	io.Writer
+	Verify() error
+}
+
+type DownloadOption struct {
+	// Verifier, if not nil, verifies the downloaded content.
+	Verifier Verifier
+	// Connections is the number of the concurrent range requests to download
+	// the content in segments. the segmented download is not resumed.
+	Connections int
+}
+
+// partial is the metadata of the partially downloaded file. it is saved next
+// to the partial file to resume the download.
+type partial struct {
+	pathname     string
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+}
+
+func loadPartial(pathname string) *partial {
+	p := &partial{}
+	if b, err := ioutil.ReadFile(pathname); err == nil {
+		if err = json.Unmarshal(b, p); err != nil {
+			log.Debug("ignore invalid metadata %s: %v", pathname, err)
+			p = &partial{}
+		}
+	}
+	p.pathname = pathname
+	return p
+}
+
+func (p *partial) save() error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.pathname, b, 0644)
+}
+
+func (p *partial) remove() {
+	if err := os.Remove(p.pathname); err != nil && !os.IsNotExist(err) {
+		log.Debug("failed to remove %s: %v", p.pathname, err)
+	}
+}
+
+// validator returns the value of the If-Range header. the weak etag cannot be
+// used for the range request.
+func (p *partial) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// parseContentRange parses the Content-Range header in the format
+// "bytes <start>-<end>/<size>". the size is -1 if it is unknown.
+func parseContentRange(s string) (start, size int64, err error) {
+	if !strings.HasPrefix(s, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	}
+	s = s[len("bytes "):]
+
+	arr := strings.SplitN(s, "/", 2)
+	rng := strings.SplitN(arr[0], "-", 2)
+	if len(arr) != 2 || len(rng) != 2 {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	} else if start, err = strconv.ParseInt(rng[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	} else if arr[1] == "*" {
+		return start, -1, nil
+	} else if size, err = strconv.ParseInt(arr[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", s)
+	}
+	return start, size, nil
+}
+
+// interruptedError is the error that occurred while receiving the content.
+// the download can be resumed from the partial file.
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+var errRangeNotSatisfiable = fmt.Errorf("range not satisfiable")
+
+// requestAsset requests the content of the asset from the offset. the
+// validator is sent as the If-Range header.
+func (c *Client) requestAsset(id int, offset int64, validator string) (*http.Response, error) {
+	u, err := resolveEndpoint(fmt.Sprintf("/releases/assets/%d", id))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.createRequest("GET", c.baseURL+u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+
+	if err = c.log(req, false); err != nil {
+		return nil, err
+	}
+	return c.do(req, retryServerError)
+}
+
+// fetchAsset writes the content of the asset into the partial file. it
+// requests the rest of the content if the partial file can be resumed.
+func (c *Client) fetchAsset(id int, f *os.File, p *partial) error {
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	} else if p.validator() == "" || offset > p.Size {
+		offset = 0
+	}
+
+	rsp, err := c.requestAsset(id, offset, p.validator())
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	size := rsp.ContentLength
+	switch rsp.StatusCode {
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(rsp.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		} else if start != offset {
+			return fmt.Errorf("unexpected Content-Range %q", rsp.Header.Get("Content-Range"))
+		}
+		log.Debug("resume download from %d/%d bytes", offset, total)
+		size = total
+
+	case http.StatusOK:
+		// the server ignored the range request or the content has changed
+		offset = 0
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		return errRangeNotSatisfiable
+
+	default:
+		return newAPIError(rsp)
+	}
+
+	if err = f.Truncate(offset); err != nil {
+		return err
+	} else if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	p.ETag = rsp.Header.Get("ETag")
+	p.LastModified = rsp.Header.Get("Last-Modified")
+	p.Size = size
+	if err = p.save(); err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, rsp.Body)
+	if err != nil {
+		return &interruptedError{err: err}
+	} else if size >= 0 && offset+n != size {
+		return &interruptedError{
+			err: fmt.Errorf("unable to download the required file size %d/%d", offset+n, size),
+		}
+	}
+	return nil
+}
+
+// DownloadAsset downloads the asset into the pathname. the content is written
+// to the partial file "<pathname>.part" and then it is moved into place after
+// verification. the interrupted download is resumed from the partial file.
+func (c *Client) DownloadAsset(id int, pathname string, o *DownloadOption) error {
+	if o == nil {
+		o = &DownloadOption{}
+	}
+
+	partname := pathname + ".part"
+	f, err := os.OpenFile(partname, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p := loadPartial(partname + ".json")
+
+	if o.Connections > 1 {
+		// the segments are not written in order, so the partial file cannot
+		// be resumed by the single stream
+		p.remove()
+		*p = partial{pathname: p.pathname}
+		if err = c.fetchAssetSegments(id, f, o.Connections); err == nil {
+			return c.moveAsset(f, p, pathname, o)
+		} else if IsNotFound(err) {
+			f.Close()
+			os.Remove(partname)
+			return err
+		} else if !errors.Is(err, errNoSegments) {
+			return err
+		}
+		log.Debug("fallback to the single stream download: %v", err)
+		if err = f.Truncate(0); err != nil {
+			return err
+		}
+	}
+
+	restarted := false
+	for attempt := 0; ; attempt++ {
+		err = c.fetchAsset(id, f, p)
+		if err == errRangeNotSatisfiable && !restarted {
+			// discard the partial file that cannot be resumed, and restart
+			// the download only once
+			restarted = true
+			log.Debug("discard the partial file %s: %v", partname, err)
+			*p = partial{pathname: p.pathname}
+			if err = f.Truncate(0); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var ie *interruptedError
+		if errors.As(err, &ie) && attempt < c.retry.MaxRetries && c.ctx.Err() == nil {
+			delay := c.retry.backoff(attempt)
+			log.Debug("resume download after %v: %v", delay, err)
+			if err = sleep(c.ctx, delay); err != nil {
+				return err
+			}
+			continue
+		} else if IsNotFound(err) {
+			// the asset has been deleted
+			f.Close()
+			os.Remove(partname)
+			p.remove()
+			return err
+		} else if err != nil {
+			return err
+		}
+		break
+	}
+
+	return c.moveAsset(f, p, pathname, o)
+}
+
+// moveAsset verifies the partial file and then moves it into place.
+func (c *Client) moveAsset(f *os.File, p *partial, pathname string, o *DownloadOption) error {
+	partname := f.Name()
+	if o.Verifier != nil {
+		// verify the whole content including the resumed part
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		} else if _, err = io.Copy(o.Verifier, f); err != nil {
+			return err
+		} else if err = o.Verifier.Verify(); err != nil {
+			f.Close()
+			os.Remove(partname)
+			p.remove()
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	p.remove()
+	return os.Rename(partname, pathname)
+}
+
+// readErrorRecorder records the error of the reader to distinguish it from
+// the error of the writer.
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrorRecorder) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// DownloadAssetTo writes the content of the asset to the writer. the
+// interrupted download is resumed from the written offset if the server
+// accepts the range request. the Connections option is ignored.
+func (c *Client) DownloadAssetTo(id int, w io.Writer, o *DownloadOption) error {
+	if o == nil {
+		o = &DownloadOption{}
+	}
+	if o.Verifier != nil {
+		w = io.MultiWriter(w, o.Verifier)
+	}
+
+	p := &partial{}
+	offset := int64(0)
+	for attempt := 0; ; attempt++ {
+		rsp, err := c.requestAsset(id, offset, p.validator())
+		if err != nil {
+			return err
+		}
+
+		size := rsp.ContentLength
+		switch rsp.StatusCode {
+		case http.StatusPartialContent:
+			start, total, err := parseContentRange(rsp.Header.Get("Content-Range"))
+			if err == nil && start != offset {
+				err = fmt.Errorf("unexpected Content-Range %q", rsp.Header.Get("Content-Range"))
+			}
+			if err != nil {
+				rsp.Body.Close()
+				return err
+			}
+			log.Debug("resume download from %d/%d bytes", offset, total)
+			size = total
+
+		case http.StatusOK:
+			if offset > 0 {
+				// the written content cannot be rewound
+				rsp.Body.Close()
+				return fmt.Errorf("unable to resume the download: the content has changed")
+			}
+			p.ETag = rsp.Header.Get("ETag")
+			p.LastModified = rsp.Header.Get("Last-Modified")
+
+		default:
+			err = newAPIError(rsp)
+			rsp.Body.Close()
+			return err
+		}
+
+		r := &readErrorRecorder{r: rsp.Body}
+		n, err := io.Copy(w, r)
+		rsp.Body.Close()
+		offset += n
+		if err == nil && size >= 0 && offset != size {
+			r.err = fmt.Errorf("unable to download the required file size %d/%d", offset, size)
+			err = r.err
+		}
+		if err == nil {
+			break
+		} else if r.err == nil || p.validator() == "" ||
+			attempt >= c.retry.MaxRetries || c.ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.retry.backoff(attempt)
+		log.Debug("resume download after %v: %v", delay, err)
+		if err = sleep(c.ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	if o.Verifier != nil {
+		return o.Verifier.Verify()
+	}
+	return nil
+}
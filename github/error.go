@@ -0,0 +1,175 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+}
+
+func parseRateLimit(h http.Header) RateLimit {
+	atoi := func(k string) int {
+		v, _ := strconv.Atoi(h.Get(k))
+		return v
+	}
+
+	rl := RateLimit{
+		Limit:     atoi("X-RateLimit-Limit"),
+		Remaining: atoi("X-RateLimit-Remaining"),
+		Used:      atoi("X-RateLimit-Used"),
+	}
+	if sec, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(sec, 0)
+	}
+	return rl
+}
+
+// APIErrorDetail is an element of the errors array in the error response.
+type APIErrorDetail struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (d *APIErrorDetail) UnmarshalJSON(b []byte) error {
+	// some endpoints return the errors as an array of strings
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, &d.Message)
+	}
+
+	type detail APIErrorDetail
+	return json.Unmarshal(b, (*detail)(d))
+}
+
+func (d APIErrorDetail) String() string {
+	if d.Message != "" {
+		return d.Message
+	}
+
+	list := []string{}
+	for _, v := range []string{d.Resource, d.Field, d.Code} {
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return strings.Join(list, " ")
+}
+
+// APIError represents the unexpected response of the GitHub API.
+type APIError struct {
+	StatusCode       int              `json:"-"`
+	Status           string           `json:"-"`
+	Method           string           `json:"-"`
+	URL              string           `json:"-"`
+	Message          string           `json:"message"`
+	DocumentationURL string           `json:"documentation_url"`
+	Errors           []APIErrorDetail `json:"errors"`
+	RateLimit        RateLimit        `json:"-"`
+	// Dump is the dump of the response including the body.
+	Dump []byte `json:"-"`
+}
+
+func newAPIError(rsp *http.Response) error {
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	e := &APIError{
+		StatusCode: rsp.StatusCode,
+		Status:     rsp.Status,
+		RateLimit:  parseRateLimit(rsp.Header),
+	}
+	if rsp.Request != nil {
+		// the url of the redirected request may be signed
+		e.Method = rsp.Request.Method
+		e.URL = redactURL(rsp.Request.URL).String()
+	}
+
+	// dump the copy of the response without the credentials
+	r := *rsp
+	r.Header = redactHeader(rsp.Header)
+	if e.Dump, err = httputil.DumpResponse(&r, true); err != nil {
+		return err
+	}
+	rsp.Body = r.Body
+	// ignore the body that is not an error object
+	_ = json.Unmarshal(b, e)
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+
+	if len(e.Errors) > 0 {
+		list := make([]string, 0, len(e.Errors))
+		for _, v := range e.Errors {
+			list = append(list, v.String())
+		}
+		s += " (" + strings.Join(list, ", ") + ")"
+	}
+	return s
+}
+
+func hasStatusCode(err error, codes ...int) bool {
+	var e *APIError
+	if errors.As(err, &e) {
+		for _, code := range codes {
+			if e.StatusCode == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+func IsUnprocessableEntity(err error) bool {
+	return hasStatusCode(err, http.StatusUnprocessableEntity)
+}
+
+func IsRateLimited(err error) bool {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return e.RateLimit.Limit > 0 && e.RateLimit.Remaining == 0 ||
+			strings.Contains(strings.ToLower(e.Message), "rate limit")
+	}
+	return false
+}
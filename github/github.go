@@ -4,15 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mah0x211/github-release-admin/log"
 )
@@ -22,8 +24,8 @@ type Client struct {
 	baseURL    string
 	repo       string
 	baseHeader http.Header
-	Header     http.Header
-	Body       io.Reader
+	httpc      *http.Client
+	retry      RetryPolicy
 }
 
 const GITHUB_API_URL = "https://api.github.com"
@@ -31,7 +33,33 @@ const GITHUB_API_URL = "https://api.github.com"
 var ReOwnerName = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
 var ReRepoName = regexp.MustCompile(`^[\w-][\w.-]*$`)
 
-func New(ctx context.Context, repo string) (*Client, error) {
+type Option func(c *Client)
+
+// WithHTTPClient uses the specified client to send all requests.
+func WithHTTPClient(httpc *http.Client) Option {
+	return func(c *Client) {
+		c.SetHTTPClient(httpc)
+	}
+}
+
+// WithTransport uses the client with the specified transport to send all
+// requests.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.SetHTTPClient(&http.Client{
+			Transport: rt,
+		})
+	}
+}
+
+// WithRetryPolicy uses the specified policy to retry the failed requests.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.SetRetryPolicy(p)
+	}
+}
+
+func New(ctx context.Context, repo string, opts ...Option) (*Client, error) {
 	if repo = strings.TrimSpace(repo); repo == "" {
 		return nil, fmt.Errorf("repo name must not be empty")
 	}
@@ -57,11 +85,34 @@ func New(ctx context.Context, repo string) (*Client, error) {
 		baseHeader: http.Header{
 			"Accept": {"application/vnd.github.v3+json"},
 		},
+		httpc: http.DefaultClient,
+		retry: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
 	return c, nil
 }
 
+// Context returns the context of the client.
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
+// WithContext returns a copy of the client that sends the requests with the
+// specified context.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		ctx:        ctx,
+		repo:       c.repo,
+		baseURL:    c.baseURL,
+		baseHeader: c.baseHeader.Clone(),
+		httpc:      c.httpc,
+		retry:      c.retry,
+	}
+}
+
 func (c *Client) SetURL(s string) error {
 	u, err := url.Parse(s)
 	if err != nil {
@@ -86,12 +137,31 @@ func (c *Client) SetToken(token string) {
 		c.baseHeader.Del("Authorization")
 	} else {
 		c.baseHeader.Set("Authorization", fmt.Sprintf("token %s", token))
+		// never output the token even if it is included in the response
+		log.AddSecret(token)
 	}
 }
 
+func (c *Client) SetHTTPClient(httpc *http.Client) {
+	if httpc == nil {
+		httpc = http.DefaultClient
+	}
+	c.httpc = httpc
+}
+
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
 func (c *Client) log(req *http.Request, body bool) error {
 	if log.Verbose {
-		b, err := httputil.DumpRequest(req, body)
+		// dump the copy of the request without the credentials
+		r := *req
+		r.Header = redactHeader(req.Header)
+		r.URL = redactURL(req.URL)
+		b, err := httputil.DumpRequest(&r, body)
+		// DumpRequest replaces the body of the copy with the unread one
+		req.Body = r.Body
 		if err != nil {
 			return err
 		}
@@ -100,28 +170,13 @@ func (c *Client) log(req *http.Request, body bool) error {
 	return nil
 }
 
-func (c *Client) createRequest(method, url string) (*http.Request, error) {
-	body := c.Body
-	header := c.Header
-	c.Body = nil
-	c.Header = nil
-
+// createRequest creates the request with the body and the base headers.
+func (c *Client) createRequest(method, url string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(c.ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header = c.baseHeader.Clone()
-	for k, v := range header {
-		list, ok := req.Header[k]
-		if !ok {
-			list = make([]string, 0, len(v))
-			copy(list, v)
-		} else {
-			list = append(list, v...)
-		}
-		req.Header[k] = list
-	}
-
 	return req, nil
 }
 
@@ -142,40 +197,56 @@ func resolveEndpoint(s string) (string, error) {
 	return u.String(), nil
 }
 
-func (c *Client) request(method, endpoint string) (*http.Response, error) {
+func (c *Client) request(method, endpoint string, body io.Reader) (*http.Response, error) {
 	u, err := resolveEndpoint(endpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := c.createRequest(method, c.baseURL+u)
+	req, err := c.createRequest(method, c.baseURL+u, body)
 	if err != nil {
 		return nil, err
 	} else if err = c.log(req, true); err != nil {
 		return nil, err
 	}
 
-	return http.DefaultClient.Do(req)
+	on := retryRateLimited
+	if isIdempotent(method) {
+		on = retryServerError
+	}
+	return c.do(req, on)
 }
 
 func (c *Client) Get(endpoint string) (*http.Response, error) {
-	return c.request("GET", endpoint)
+	return c.request("GET", endpoint, nil)
+}
+
+func (c *Client) Post(endpoint string, body io.Reader) (*http.Response, error) {
+	return c.request("POST", endpoint, body)
 }
 
-func (c *Client) Post(endpoint string) (*http.Response, error) {
-	return c.request("POST", endpoint)
+func (c *Client) Patch(endpoint string, body io.Reader) (*http.Response, error) {
+	return c.request("PATCH", endpoint, body)
 }
 
 func (c *Client) Delete(endpoint string) (*http.Response, error) {
-	return c.request("DELETE", endpoint)
+	return c.request("DELETE", endpoint, nil)
 }
 
 func (c *Client) upload(method, endpoint string, body io.Reader, size int64, mime string) (*http.Response, error) {
-	c.Body = body
-	req, err := c.createRequest(method, endpoint)
+	// the body can be sent again only if it can be rewound to the current
+	// offset
+	getBody, err := rewindableBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// prevent the transport from closing the body before retrying
+	req, err := c.createRequest(method, endpoint, ioutil.NopCloser(body))
 	if err != nil {
 		return nil, err
 	}
+	req.GetBody = getBody
 	req.ContentLength = size
 	req.Header.Set("Content-Type", mime)
 	req.Header.Set("Expect", "100-continue")
@@ -184,85 +255,108 @@ func (c *Client) upload(method, endpoint string, body io.Reader, size int64, mim
 		return nil, err
 	}
 
-	return http.DefaultClient.Do(req)
+	// the upload that failed with 5xx is not retried since the asset may
+	// have been created, and the retry fails with 422 already_exists
+	on := retryRateLimited
+	if getBody != nil {
+		on = retryTransportError
+	}
+	return c.do(req, on)
 }
 
 func (c *Client) PostUpload(endpoint string, body io.Reader, size int64, mime string) (*http.Response, error) {
 	return c.upload("POST", endpoint, body, size, mime)
 }
 
-func (c *Client) DownloadAsset(id int, pathname string) error {
-	dir := os.TempDir()
-	f, err := os.CreateTemp(dir, "ghr-download-*")
+func (c *Client) DeleteTag(tag string) error {
+	rsp, err := c.Delete(fmt.Sprintf("/git/refs/tags/%s", tag))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	defer os.Remove(f.Name())
+	defer rsp.Body.Close()
 
-	u, err := resolveEndpoint(fmt.Sprintf("/releases/assets/%d", id))
-	if err != nil {
-		return err
-	}
+	switch rsp.StatusCode {
+	case http.StatusNoContent, http.StatusUnprocessableEntity:
+		return nil
 
-	req, err := c.createRequest("GET", c.baseURL+u)
-	if err != nil {
-		return err
+	default:
+		return newAPIError(rsp)
 	}
-	req.Header.Set("Accept", "application/octet-stream")
+}
 
-	if err = c.log(req, false); err != nil {
-		return err
-	}
+type TagCommit struct {
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
 
-	rsp, err := http.DefaultClient.Do(req)
+type Tag struct {
+	Name       string    `json:"name"`
+	Commit     TagCommit `json:"commit"`
+	ZipballURL string    `json:"zipball_url"`
+	TarballURL string    `json:"tarball_url"`
+}
+
+type ListTags struct {
+	NextPage int
+	Tags     []*Tag
+}
+
+func (c *Client) ListTags(page, perPage int) (*ListTags, error) {
+	rsp, err := c.Get(fmt.Sprintf("/tags?per_page=%d&page=%d", perPage, page))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rsp.Body.Close()
 
 	switch rsp.StatusCode {
 	case http.StatusOK:
-		size := rsp.ContentLength
-		if n, err := io.Copy(f, rsp.Body); err != nil {
-			return err
-		} else if n != size {
-			return fmt.Errorf("unable to download the required file size %d/%d", n, size)
-		} else if err = os.Rename(f.Name(), pathname); err != nil {
-			return err
+		list := &ListTags{}
+		if err := json.NewDecoder(rsp.Body).Decode(&list.Tags); err != nil {
+			return nil, err
 		}
-		return nil
+
+		for _, v := range rsp.Header.Values("Link") {
+			if page, err = c.getNextPage(v); err != nil {
+				log.Errorf("invalid Link header: %v", err)
+			} else {
+				list.NextPage = page
+			}
+		}
+
+		return list, nil
 
 	case http.StatusNotFound:
-		return nil
+		return &ListTags{}, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, false)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return err
+		return nil, newAPIError(rsp)
 	}
 }
 
-func (c *Client) DeleteTag(tag string) error {
-	rsp, err := c.Delete(fmt.Sprintf("/git/refs/tags/%s", tag))
-	if err != nil {
-		return err
-	}
-	defer rsp.Body.Close()
+type FetchTagCallback func(v *Tag, page int) error
 
-	switch rsp.StatusCode {
-	case http.StatusNoContent, http.StatusUnprocessableEntity:
-		return nil
+func (c *Client) FetchTag(page, perPage int, fn FetchTagCallback) error {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
 
-	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
+	for page > 0 {
+		list, err := c.ListTags(page, perPage)
+		if err != nil {
+			return err
 		}
-		return err
+		for _, v := range list.Tags {
+			if err = fn(v, page); err != nil {
+				return err
+			}
+		}
+		page = list.NextPage
 	}
+
+	return nil
 }
 
 type Author struct {
@@ -289,24 +383,43 @@ type Asset struct {
 }
 
 type Release struct {
-	ID              int     `json:"id"`
-	Draft           bool    `json:"draft"`
-	PreRelease      bool    `json:"prerelease"`
-	Name            string  `json:"name"`
-	Body            string  `json:"body"`
-	TagName         string  `json:"tag_name"`
-	TargetCommitish string  `json:"target_commitish"`
-	HtmlURL         string  `json:"html_url,omitempty"`
-	UploadURL       string  `json:"upload_url,omitempty"`
-	CreatedAt       string  `json:"created_at,omitempty"`
-	PublishedAt     string  `json:"published_at,omitempty"`
-	Author          Author  `json:"author,omitempty"`
-	Assets          []Asset `json:"assets,omitempty"`
+	ID              int        `json:"id"`
+	Draft           bool       `json:"draft"`
+	PreRelease      bool       `json:"prerelease"`
+	Name            string     `json:"name"`
+	Body            string     `json:"body"`
+	TagName         string     `json:"tag_name"`
+	TargetCommitish string     `json:"target_commitish"`
+	HtmlURL         string     `json:"html_url,omitempty"`
+	UploadURL       string     `json:"upload_url,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	Author          Author     `json:"author,omitempty"`
+	Assets          []Asset    `json:"assets,omitempty"`
+}
+
+// ReleasedAt returns the published_at, or the created_at if the release has
+// not been published. it returns the zero time if the release has neither.
+func (r *Release) ReleasedAt() time.Time {
+	if r.PublishedAt != nil {
+		return *r.PublishedAt
+	} else if r.CreatedAt != nil {
+		return *r.CreatedAt
+	}
+	return time.Time{}
 }
 
 var ReUploadURLSuffix = regexp.MustCompile("/assets[^/]*$")
 
-func (r *Release) UploadAsset(c *Client, name string, body io.Reader, size int64, mime string) error {
+// UploadAsset uploads the body as an asset of the release. the body is
+// streamed to the server, and the progress is reported to the progress
+// function if it is not nil.
+func (r *Release) UploadAsset(c *Client, name string, body io.Reader, size int64, mime string, progress ProgressFunc) error {
+	body, err := withProgress(body, size, progress)
+	if err != nil {
+		return err
+	}
+
 	baseURL := ReUploadURLSuffix.ReplaceAllString(r.UploadURL, "")
 	endpoint := fmt.Sprintf("%s/assets?name=%s", baseURL, name)
 	rsp, err := c.upload("POST", endpoint, body, size, mime)
@@ -320,11 +433,7 @@ func (r *Release) UploadAsset(c *Client, name string, body io.Reader, size int64
 		return nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return err
+		return newAPIError(rsp)
 	}
 }
 
@@ -334,7 +443,8 @@ type ListReleases struct {
 }
 
 // ReLinkNext is used to check the Link header
-// 	<https://api.github.com/repositories/194783954/releases?per_page=1&page=2>; rel=\"next\"
+//
+//	<https://api.github.com/repositories/194783954/releases?per_page=1&page=2>; rel=\"next\"
 var ReLinkNext = regexp.MustCompile(`<([^>]+)>; rel="next"`)
 
 func (c *Client) getNextPage(link string) (int, error) {
@@ -379,11 +489,7 @@ func (c *Client) ListReleases(page, perPage int) (*ListReleases, error) {
 		return &ListReleases{}, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -413,8 +519,19 @@ func (c *Client) FetchRelease(page, perPage int, fn FetchReleaseCallback) error
 	return nil
 }
 
+// createReleaseParams is the request body of CreateRelease. the Release type
+// cannot be used since it has the read-only fields.
+type createReleaseParams struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	PreRelease      bool   `json:"prerelease"`
+}
+
 func (c *Client) CreateRelease(tagName, targetCommitish, name, body string, draft, prerelease bool) (*Release, error) {
-	b, err := json.Marshal(&Release{
+	b, err := json.Marshal(&createReleaseParams{
 		TagName:         tagName,
 		TargetCommitish: targetCommitish,
 		Name:            name,
@@ -426,8 +543,7 @@ func (c *Client) CreateRelease(tagName, targetCommitish, name, body string, draf
 		return nil, err
 	}
 
-	c.Body = bytes.NewBuffer(b)
-	rsp, err := c.Post("/releases")
+	rsp, err := c.Post("/releases", bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -442,11 +558,7 @@ func (c *Client) CreateRelease(tagName, targetCommitish, name, body string, draf
 		return release, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -458,12 +570,143 @@ func (c *Client) DeleteRelease(id int) error {
 	defer rsp.Body.Close()
 
 	if rsp.StatusCode != http.StatusNoContent {
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
+		return newAPIError(rsp)
+	}
+
+	return nil
+}
+
+func (c *Client) GetAsset(id int) (*Asset, error) {
+	rsp, err := c.Get(fmt.Sprintf("/releases/assets/%d", id))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+
+	case http.StatusOK:
+		asset := &Asset{}
+		if err := json.NewDecoder(rsp.Body).Decode(&asset); err != nil {
+			return nil, err
 		}
+		return asset, nil
+
+	default:
+		return nil, newAPIError(rsp)
+	}
+}
+
+// UpdateAssetParams is the parameters to update the asset.
+// the nil fields are not changed.
+type UpdateAssetParams struct {
+	Name  *string `json:"name,omitempty"`
+	Label *string `json:"label,omitempty"`
+}
+
+func (c *Client) UpdateAsset(id int, p *UpdateAssetParams) (*Asset, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := c.Patch(fmt.Sprintf("/releases/assets/%d", id), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+
+	case http.StatusOK:
+		asset := &Asset{}
+		if err := json.NewDecoder(rsp.Body).Decode(&asset); err != nil {
+			return nil, err
+		}
+		return asset, nil
+
+	default:
+		return nil, newAPIError(rsp)
+	}
+}
+
+type ListAssets struct {
+	NextPage int
+	Assets   []*Asset
+}
+
+func (c *Client) ListAssets(releaseID, page, perPage int) (*ListAssets, error) {
+	rsp, err := c.Get(fmt.Sprintf("/releases/%d/assets?per_page=%d&page=%d", releaseID, perPage, page))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		list := &ListAssets{}
+		if err = json.NewDecoder(rsp.Body).Decode(&list.Assets); err != nil {
+			return nil, err
+		}
+
+		for _, v := range rsp.Header.Values("Link") {
+			if page, err = c.getNextPage(v); err != nil {
+				log.Errorf("invalid Link header: %v", err)
+			} else {
+				list.NextPage = page
+			}
+		}
+
+		return list, nil
+
+	case http.StatusNotFound:
+		return &ListAssets{}, nil
+
+	default:
+		return nil, newAPIError(rsp)
+	}
+}
+
+type FetchAssetCallback func(v *Asset, page int) error
+
+func (c *Client) FetchAsset(releaseID, page, perPage int, fn FetchAssetCallback) error {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+
+	for page > 0 {
+		list, err := c.ListAssets(releaseID, page, perPage)
+		if err != nil {
+			return err
+		}
+		for _, v := range list.Assets {
+			if err = fn(v, page); err != nil {
+				return err
+			}
+		}
+		page = list.NextPage
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteAsset(id int) error {
+	rsp, err := c.Delete(fmt.Sprintf("/releases/assets/%d", id))
+	if err != nil {
 		return err
 	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusNoContent {
+		return newAPIError(rsp)
+	}
 
 	return nil
 }
@@ -487,11 +730,7 @@ func (c *Client) GetRelease(id int) (*Release, error) {
 		return release, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -514,12 +753,72 @@ func (c *Client) GetReleaseByTagName(tag string) (*Release, error) {
 		return release, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
+		return nil, newAPIError(rsp)
+	}
+}
+
+var errFound = errors.New("found")
+
+// FindReleaseByTagName returns the release associated with the tag. unlike
+// GetReleaseByTagName, the draft release is also looked up from the list of
+// releases since it cannot be found by the tag name.
+func (c *Client) FindReleaseByTagName(tag string) (*Release, error) {
+	if v, err := c.GetReleaseByTagName(tag); err != nil || v != nil {
+		return v, err
+	}
+
+	var found *Release
+	if err := c.FetchRelease(1, 0, func(v *Release, _ int) error {
+		if v.Draft && v.TagName == tag {
+			found = v
+			return errFound
 		}
+		return nil
+	}); err != nil && !errors.Is(err, errFound) {
 		return nil, err
 	}
+	return found, nil
+}
+
+// UpdateReleaseParams is the parameters to update the release.
+// the nil fields are not changed.
+type UpdateReleaseParams struct {
+	TagName         *string `json:"tag_name,omitempty"`
+	TargetCommitish *string `json:"target_commitish,omitempty"`
+	Name            *string `json:"name,omitempty"`
+	Body            *string `json:"body,omitempty"`
+	Draft           *bool   `json:"draft,omitempty"`
+	PreRelease      *bool   `json:"prerelease,omitempty"`
+	// MakeLatest must be "true", "false" or "legacy". (empty: not changed)
+	MakeLatest string `json:"make_latest,omitempty"`
+}
+
+func (c *Client) UpdateRelease(id int, p *UpdateReleaseParams) (*Release, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := c.Patch(fmt.Sprintf("/releases/%d", id), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+
+	case http.StatusOK:
+		release := &Release{}
+		if err := json.NewDecoder(rsp.Body).Decode(&release); err != nil {
+			return nil, err
+		}
+		return release, nil
+
+	default:
+		return nil, newAPIError(rsp)
+	}
 }
 
 func (c *Client) GetReleaseLatest() (*Release, error) {
@@ -541,17 +840,19 @@ func (c *Client) GetReleaseLatest() (*Release, error) {
 		return release, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
+type BranchCommit struct {
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
+
 type Branch struct {
-	Name      string `json:"name"`
-	Protected bool   `json:"protected"`
+	Name      string       `json:"name"`
+	Commit    BranchCommit `json:"commit"`
+	Protected bool         `json:"protected"`
 }
 
 func (c *Client) GetBranch(name string) (*Branch, error) {
@@ -573,11 +874,7 @@ func (c *Client) GetBranch(name string) (*Branch, error) {
 		return nil, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -614,11 +911,7 @@ func (c *Client) ListBranches(page, perPage int) (*ListBranches, error) {
 		return &ListBranches{}, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -703,11 +996,7 @@ func (c *Client) ListCommitRefs(sha string, page, perPage int) (*ListCommitRefs,
 		return &ListCommitRefs{}, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -738,7 +1027,7 @@ func (c *Client) FetchCommitRef(sha string, page, perPage int, fn FetchCommitRef
 }
 
 type CompareTwoCommit struct {
-	HtmlURL         string       `json:"html_url`
+	HtmlURL         string       `json:"html_url"`
 	BaseCommit      CommitRef    `json:"base_commit"`
 	MergeBaseCommit CommitRef    `json:"merge_base_commit"`
 	Status          string       `json:"status"`
@@ -777,11 +1066,7 @@ func (c *Client) CompareTwoCommit(base, head string, page, perPage int) (*Compar
 		return nil, nil
 
 	default:
-		b, err := httputil.DumpResponse(rsp, true)
-		if err == nil {
-			err = fmt.Errorf("%s", b)
-		}
-		return nil, err
+		return nil, newAPIError(rsp)
 	}
 }
 
@@ -0,0 +1,63 @@
+package github
+
+import "io"
+
+// ProgressFunc is called with the number of bytes transferred so far and the
+// total size.
+type ProgressFunc func(n, size int64)
+
+type progressReader struct {
+	r    io.Reader
+	n    int64
+	size int64
+	fn   ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.n += int64(n)
+		p.fn(p.n, p.size)
+	}
+	return n, err
+}
+
+// progressReadSeeker resets the progress when the body is rewound to retry
+// the request.
+type progressReadSeeker struct {
+	progressReader
+	base int64
+}
+
+func (p *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := p.r.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		p.n = pos - p.base
+	}
+	return pos, err
+}
+
+// withProgress returns the reader that reports the progress of reading the
+// body to the fn. the returned reader is an io.Seeker if the body is.
+func withProgress(body io.Reader, size int64, fn ProgressFunc) (io.Reader, error) {
+	if fn == nil {
+		return body, nil
+	}
+
+	pr := progressReader{
+		r:    body,
+		size: size,
+		fn:   fn,
+	}
+	if s, ok := body.(io.Seeker); ok {
+		base, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		return &progressReadSeeker{
+			progressReader: pr,
+			base:           base,
+		}, nil
+	}
+	return &pr, nil
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// sensitiveHeaders are the headers that are masked in the verbose output.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// sensitiveQueries are the query parameters that are masked in the verbose
+// output. the signed url of the asset download contains some of them.
+var sensitiveQueries = map[string]bool{
+	"access_token":         true,
+	"token":                true,
+	"client_secret":        true,
+	"jwt":                  true,
+	"sig":                  true,
+	"signature":            true,
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+}
+
+func redactURL(u *url.URL) *url.URL {
+	v := *u
+	if _, ok := v.User.Password(); ok {
+		v.User = url.UserPassword(v.User.Username(), log.Mask)
+	}
+
+	if v.RawQuery != "" {
+		q := v.Query()
+		for k := range q {
+			if sensitiveQueries[strings.ToLower(k)] {
+				q.Set(k, log.Mask)
+			}
+		}
+		v.RawQuery = q.Encode()
+	}
+	return &v
+}
+
+func redactURLString(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return redactURL(u).String()
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range sensitiveHeaders {
+		if _, ok := h[k]; ok {
+			h.Set(k, log.Mask)
+		}
+	}
+	if v := h.Get("Location"); v != "" {
+		h.Set("Location", redactURLString(v))
+	}
+	return h
+}
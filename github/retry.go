@@ -0,0 +1,213 @@
+package github
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// RetryPolicy describes how the failed requests are retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries. (0 disables retrying)
+	MaxRetries int
+	// MinDelay is the base delay of the exponential backoff.
+	MinDelay time.Duration
+	// MaxDelay is the upper limit of the exponential backoff.
+	MaxDelay time.Duration
+	// MaxWait is the upper limit of the delay requested by the server with
+	// the Retry-After or X-RateLimit-Reset header. the response is returned
+	// as it is if the server requests to wait longer than this.
+	MaxWait time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinDelay:   time.Second,
+	MaxDelay:   30 * time.Second,
+	MaxWait:    5 * time.Minute,
+}
+
+// retryOn specifies the failures that the request is retried on. the
+// rate-limited request is always retried since it has not been processed by
+// the server.
+type retryOn int
+
+const (
+	// retryRateLimited retries only the rate-limited request.
+	retryRateLimited retryOn = iota
+	// retryTransportError also retries the request that failed to be sent.
+	retryTransportError
+	// retryServerError also retries the request that failed with 502, 503
+	// or 504. the server may have processed it, so it must be idempotent.
+	retryServerError
+)
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// backoff returns the exponential backoff delay with jitter for the attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinDelay
+	for i := 0; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// serverDelay returns the delay requested by the server. the second return
+// value is false if the server did not request it.
+func serverDelay(rsp *http.Response, now time.Time) (time.Duration, bool) {
+	if v := rsp.Header.Get("Retry-After"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil {
+			return time.Duration(sec) * time.Second, true
+		} else if t, err := http.ParseTime(v); err == nil {
+			return t.Sub(now), true
+		}
+	}
+
+	if rsp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if v := rsp.Header.Get("X-RateLimit-Reset"); v != "" {
+			if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return time.Unix(sec, 0).Sub(now), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func isRateLimited(rsp *http.Response) bool {
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return rsp.Header.Get("Retry-After") != "" ||
+			rsp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// retryDelay returns the delay before retrying the request. the second return
+// value is false if the request should not be retried.
+func (p RetryPolicy) retryDelay(attempt int, rsp *http.Response, err error, on retryOn) (time.Duration, bool) {
+	if err != nil {
+		// transport error
+		return p.backoff(attempt), on >= retryTransportError
+	}
+
+	switch {
+	case isRateLimited(rsp):
+		// the rate-limited request has not been processed by the server,
+		// so it can be retried regardless of the method
+	case rsp.StatusCode == http.StatusBadGateway ||
+		rsp.StatusCode == http.StatusServiceUnavailable ||
+		rsp.StatusCode == http.StatusGatewayTimeout:
+		if on < retryServerError {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if d, ok := serverDelay(rsp, time.Now()); ok {
+		if d > p.MaxWait {
+			return 0, false
+		} else if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return p.backoff(attempt), true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// rewindableBody returns the function that rewinds the body to the current
+// offset. it returns nil if the body is not an io.Seeker.
+func rewindableBody(body io.Reader) (func() (io.ReadCloser, error), error) {
+	s, ok := body.(io.Seeker)
+	if !ok {
+		return nil, nil
+	}
+
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(body), nil
+	}, nil
+}
+
+// do sends the request and retries it according to the retry policy.
+// the request with a body is retried only if the req.GetBody is defined.
+func (c *Client) do(req *http.Request, on retryOn) (*http.Response, error) {
+	return c.doWith(c.httpc, req, on)
+}
+
+func (c *Client) doWith(httpc *http.Client, req *http.Request, on retryOn) (*http.Response, error) {
+	p := c.retry
+	canRewind := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		rsp, err := httpc.Do(req)
+		if attempt >= p.MaxRetries || !canRewind || req.Context().Err() != nil {
+			return rsp, err
+		}
+
+		delay, ok := p.retryDelay(attempt, rsp, err, on)
+		if !ok {
+			return rsp, err
+		} else if rsp != nil {
+			// discard the response to reuse the connection
+			_, _ = io.Copy(ioutil.Discard, rsp.Body)
+			rsp.Body.Close()
+			log.Debug("retry %s %s after %v: %s", req.Method, req.URL.Path, delay, rsp.Status)
+		} else {
+			log.Debug("retry %s %s after %v: %v", req.Method, req.URL.Path, delay, err)
+		}
+
+		if err = sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		next := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if next.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		req = next
+	}
+}
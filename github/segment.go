@@ -0,0 +1,245 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+// minSegmentSize is the minimum size of the segment of the parallel download.
+const minSegmentSize = 1 << 20
+
+// errNoSegments is returned if the asset cannot be downloaded in segments.
+var errNoSegments = fmt.Errorf("segmented download is not available")
+
+// maxDiscardSize is the maximum size of the response body that is read to
+// reuse the connection, or to report the error.
+const maxDiscardSize = 64 << 10
+
+// discard discards the response to reuse the connection. the connection of
+// the large response is closed without reading the rest of it.
+func discard(rsp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxDiscardSize))
+	rsp.Body.Close()
+}
+
+// resolveAsset returns the request of the asset content that the asset
+// endpoint redirects to, and the size of the content.
+func (c *Client) resolveAsset(id int) (*http.Request, int64, error) {
+	u, err := resolveEndpoint(fmt.Sprintf("/releases/assets/%d", id))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := c.createRequest("GET", c.baseURL+u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("Range", "bytes=0-0")
+	if err = c.log(req, false); err != nil {
+		return nil, 0, err
+	}
+
+	// follow the redirect only once to send the range requests to the
+	// content server directly
+	httpc := *c.httpc
+	httpc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	rsp, err := c.doWith(&httpc, req, retryServerError)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer discard(rsp)
+
+	switch rsp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		loc, err := rsp.Location()
+		if err != nil {
+			return nil, 0, err
+		}
+		log.Debug("asset %d is redirected to %s", id, redactURL(loc))
+		// the signed url must not receive the credentials
+		if req, err = http.NewRequestWithContext(c.ctx, "GET", loc.String(), nil); err != nil {
+			return nil, 0, err
+		}
+		return c.probeRange(req)
+
+	case http.StatusPartialContent:
+		_, size, err := parseContentRange(rsp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, 0, err
+		}
+		req.Header.Del("Range")
+		return req, size, nil
+
+	case http.StatusOK:
+		return nil, 0, errNoSegments
+
+	default:
+		return nil, 0, newAPIError(rsp)
+	}
+}
+
+// probeRange returns the size of the content if the server accepts the range
+// requests.
+func (c *Client) probeRange(req *http.Request) (*http.Request, int64, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("Range", "bytes=0-0")
+	rsp, err := c.do(r, retryServerError)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer discard(rsp)
+
+	switch rsp.StatusCode {
+	case http.StatusPartialContent:
+		_, size, err := parseContentRange(rsp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, 0, err
+		} else if size < 0 {
+			return nil, 0, errNoSegments
+		}
+		return req, size, nil
+
+	case http.StatusOK:
+		return nil, 0, errNoSegments
+
+	default:
+		return nil, 0, newAPIError(rsp)
+	}
+}
+
+// offsetWriter writes the data to the file from the offset.
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(b []byte) (int, error) {
+	n, err := w.f.WriteAt(b, w.off)
+	w.off += int64(n)
+	return n, err
+}
+
+// fetchRange writes the range of the content from start to end (inclusive)
+// into the file. it returns the number of bytes written.
+func (c *Client) fetchRange(ctx context.Context, req *http.Request, f *os.File, start, end int64) (int64, error) {
+	req = req.Clone(ctx)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	rsp, err := c.httpc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer discard(rsp)
+
+	switch {
+	case rsp.StatusCode == http.StatusOK:
+		// the server ignored the range request and sends the whole content
+		return 0, errNoSegments
+	case rsp.StatusCode >= 400:
+		body := rsp.Body
+		rsp.Body = ioutil.NopCloser(io.LimitReader(body, maxDiscardSize))
+		err = newAPIError(rsp)
+		rsp.Body = body
+		return 0, err
+	case rsp.StatusCode != http.StatusPartialContent:
+		return 0, fmt.Errorf("unexpected response of the range request: %s", rsp.Status)
+	}
+
+	if v, _, err := parseContentRange(rsp.Header.Get("Content-Range")); err != nil {
+		return 0, err
+	} else if v != start {
+		return 0, fmt.Errorf("unexpected Content-Range %q", rsp.Header.Get("Content-Range"))
+	}
+
+	size := end - start + 1
+	n, err := io.Copy(&offsetWriter{f: f, off: start}, io.LimitReader(rsp.Body, size))
+	if err == nil && n != size {
+		err = fmt.Errorf("unable to download the required segment size %d/%d", n, size)
+	}
+	return n, err
+}
+
+// fetchSegment writes the segment into the file. the failed segment is
+// retried from the received offset according to the retry policy.
+func (c *Client) fetchSegment(ctx context.Context, req *http.Request, f *os.File, start, end int64) error {
+	for attempt := 0; ; attempt++ {
+		n, err := c.fetchRange(ctx, req, f, start, end)
+		start += n
+		if err == nil {
+			return nil
+		} else if err == errNoSegments || attempt >= c.retry.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.retry.backoff(attempt)
+		log.Debug("retry segment %d-%d after %v: %v", start, end, delay, err)
+		if err = sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchAssetSegments writes the content of the asset into the file with the
+// concurrent range requests.
+func (c *Client) fetchAssetSegments(id int, f *os.File, connections int) error {
+	req, size, err := c.resolveAsset(id)
+	if err != nil {
+		return err
+	}
+
+	n := int64(connections)
+	if max := (size + minSegmentSize - 1) / minSegmentSize; n > max {
+		n = max
+	}
+	if n < 2 {
+		return errNoSegments
+	} else if err = f.Truncate(size); err != nil {
+		return err
+	}
+	log.Debug("download %d bytes with %d connections", size, n)
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	segSize := size / n
+	for i := int64(0); i < n; i++ {
+		start := i * segSize
+		end := start + segSize - 1
+		if i == n-1 {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		go func(i, start, end int64) {
+			defer wg.Done()
+			if errs[i] = c.fetchSegment(ctx, req, f, start, end); errs[i] != nil {
+				// stop the other segments
+				cancel()
+			}
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	if err = c.ctx.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package testutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewClient starts the test server with the handler, and returns the client
+// of the "owner/repo" repository that sends the requests to the server. the
+// failed requests are not retried.
+func NewClient(t *testing.T, h http.Handler) (*github.Client, *httptest.Server) {
+	ts := httptest.NewServer(h)
+	ghc, err := github.New(context.Background(), "owner/repo")
+	assert.NoError(t, err)
+	assert.NoError(t, ghc.SetURL(ts.URL))
+	ghc.SetRetryPolicy(github.RetryPolicy{})
+	return ghc, ts
+}
@@ -2,9 +2,14 @@ package list
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"sort"
 
 	"github.com/mah0x211/github-release-admin/github"
 	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
+	"github.com/mah0x211/github-release-admin/util"
 )
 
 type Option struct {
@@ -12,6 +17,36 @@ type Option struct {
 	MaxItems     uint64
 	BranchExists bool
 	Branch       string
+	TagName      string
+	AsRegex      bool
+	AsPosix      bool
+	// Semver lists only the releases that tag is a semantic version that
+	// satisfies the constraint.
+	Semver *semver.Constraint
+	// Sort sorts the releases from newest to oldest. (default: the order of
+	// the API response)
+	Sort SortBy
+}
+
+type SortBy string
+
+const (
+	// SortBySemver sorts the releases by the semantic version of the tag.
+	// the releases that tag is not a semantic version are placed last.
+	SortBySemver SortBy = "semver"
+	// SortByCreated sorts the releases by the created_at.
+	SortByCreated SortBy = "created"
+	// SortByPublished sorts the releases by the published_at. the releases
+	// that have not been published are placed last.
+	SortByPublished SortBy = "published"
+)
+
+func ParseSortBy(s string) (SortBy, error) {
+	switch v := SortBy(s); v {
+	case SortBySemver, SortByCreated, SortByPublished:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid sort %q", s)
 }
 
 const (
@@ -21,7 +56,7 @@ const (
 	flgAll          = 0x3
 )
 
-func isListTarget(v *github.Release, flg int, o *Option) bool {
+func isListTarget(v *github.Release, flg int, o *Option, re *regexp.Regexp) bool {
 	if flg == flgReleaseOnly {
 		if v.Draft || v.PreRelease {
 			log.Debug("ignore draft or prerelease: %d", v.ID)
@@ -41,18 +76,54 @@ func isListTarget(v *github.Release, flg int, o *Option) bool {
 	if o.Branch != "" && o.Branch != v.TargetCommitish {
 		log.Debug("ignore release that branch does not matched to %q: %d", o.Branch, v.ID)
 		return false
+	} else if !util.MatchName(re, o.TagName, v.TagName) {
+		log.Debug("ignore release that tag-name does not matched to %q: %d", o.TagName, v.ID)
+		return false
+	}
+
+	if o.Semver != nil && !o.Semver.Match(v.TagName) {
+		log.Debug("ignore release that tag-name does not satisfy %q: %d", o.Semver, v.ID)
+		return false
 	}
 	return true
 }
 
+// isNewer returns true if a is newer than b.
+func isNewer(a, b *github.Release, sortBy SortBy) bool {
+	switch sortBy {
+	case SortBySemver:
+		x, xerr := semver.Parse(a.TagName)
+		y, yerr := semver.Parse(b.TagName)
+		if xerr != nil || yerr != nil {
+			return xerr == nil && yerr != nil
+		}
+		return x.Compare(y) > 0
+
+	case SortByPublished:
+		if a.PublishedAt == nil || b.PublishedAt == nil {
+			return a.PublishedAt != nil && b.PublishedAt == nil
+		}
+		return a.PublishedAt.After(*b.PublishedAt)
+	}
+	if a.CreatedAt == nil || b.CreatedAt == nil {
+		return a.CreatedAt != nil && b.CreatedAt == nil
+	}
+	return a.CreatedAt.After(*b.CreatedAt)
+}
+
 var errEOL = errors.New("eol")
 
 func listup(ghc *github.Client, flg int, o *Option) ([]*github.Release, error) {
 	list := []*github.Release{}
 	nitem := uint64(0)
 
+	re, err := util.CompileName(o.TagName, o.AsRegex, o.AsPosix)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := ghc.FetchRelease(1, o.ItemsPerPage, func(v *github.Release, _ int) error {
-		if !isListTarget(v, flg, o) {
+		if !isListTarget(v, flg, o, re) {
 			return nil
 		} else if o.BranchExists {
 			if b, err := ghc.GetBranch(v.TargetCommitish); err != nil {
@@ -66,7 +137,8 @@ func listup(ghc *github.Client, flg int, o *Option) ([]*github.Release, error) {
 
 		list = append(list, v)
 		nitem++
-		if o.MaxItems > 0 && nitem >= o.MaxItems {
+		// all releases are required to sort
+		if o.MaxItems > 0 && nitem >= o.MaxItems && o.Sort == "" {
 			return errEOL
 		}
 
@@ -75,6 +147,15 @@ func listup(ghc *github.Client, flg int, o *Option) ([]*github.Release, error) {
 		return nil, err
 	}
 
+	if o.Sort != "" {
+		sort.SliceStable(list, func(i, j int) bool {
+			return isNewer(list[i], list[j], o.Sort)
+		})
+		if o.MaxItems > 0 && uint64(len(list)) > o.MaxItems {
+			list = list[:o.MaxItems]
+		}
+	}
+
 	return list, nil
 }
 
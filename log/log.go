@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/mah0x211/github-release-admin/util"
 )
@@ -12,8 +15,49 @@ var Stdout io.Writer = os.Stdout
 var Stderr io.Writer = os.Stderr
 var Verbose bool
 
+// Redactor returns the string with the secrets masked.
+type Redactor func(s string) string
+
+const Mask = "[REDACTED]"
+
+// ReGitHubToken matches the GitHub tokens. (e.g. ghp_..., github_pat_...)
+var ReGitHubToken = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)
+
+var redactMutex sync.RWMutex
+var redactors = []Redactor{
+	func(s string) string {
+		return ReGitHubToken.ReplaceAllString(s, Mask)
+	},
+}
+
+// AddRedactor adds the function that is applied to all outputs.
+func AddRedactor(fn Redactor) {
+	redactMutex.Lock()
+	defer redactMutex.Unlock()
+	redactors = append(redactors, fn)
+}
+
+// AddSecret masks all occurrences of the secret in all outputs.
+func AddSecret(secret string) {
+	if secret = strings.TrimSpace(secret); secret != "" {
+		AddRedactor(func(s string) string {
+			return strings.ReplaceAll(s, secret, Mask)
+		})
+	}
+}
+
+// Redact applies all redactors to the string.
+func Redact(s string) string {
+	redactMutex.RLock()
+	defer redactMutex.RUnlock()
+	for _, fn := range redactors {
+		s = fn(s)
+	}
+	return s
+}
+
 func fprintln(w io.Writer, a ...interface{}) {
-	fmt.Fprintln(w, a...)
+	fmt.Fprint(w, Redact(fmt.Sprintln(a...)))
 }
 
 func Error(a ...interface{}) {
@@ -32,7 +76,7 @@ func Fatal(a ...interface{}) {
 }
 
 func fprintf(w io.Writer, format string, a ...interface{}) {
-	fmt.Fprintf(w, format+"\n", a...)
+	fmt.Fprint(w, Redact(fmt.Sprintf(format+"\n", a...)))
 }
 
 func Errorf(format string, a ...interface{}) {
@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -103,3 +104,34 @@ func Test_Fatalf(t *testing.T) {
 	assert.Equal(t, "format \"hello\"\n", b.String())
 	assert.Equal(t, 1, exitCode)
 }
+
+func Test_Redact(t *testing.T) {
+	w := Stdout
+	b := bytes.NewBuffer(nil)
+	Stdout = b
+	list := redactors
+	defer func() {
+		Stdout = w
+		redactors = list
+	}()
+
+	// test that mask the GitHub tokens by default
+	token := "ghp_" + strings.Repeat("a", 36)
+	Printf("Authorization: token %s", token)
+	assert.Equal(t, "Authorization: token [REDACTED]\n", b.String())
+
+	// test that mask the registered secrets
+	b.Reset()
+	AddSecret("my-secret")
+	Print("value:", "my-secret", "other")
+	assert.Equal(t, "value: [REDACTED] other\n", b.String())
+
+	// test that apply the registered redactor
+	b.Reset()
+	AddRedactor(func(s string) string {
+		return strings.ReplaceAll(s, "other", "***")
+	})
+	Printf("value: %s", "other")
+	assert.Equal(t, "value: ***\n", b.String())
+	assert.Equal(t, "[REDACTED] ***", Redact("my-secret other"))
+}
@@ -0,0 +1,155 @@
+package publish
+
+import (
+	"fmt"
+
+	"github.com/mah0x211/github-release-admin/edit"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/list"
+	"github.com/mah0x211/github-release-admin/log"
+)
+
+type Option struct {
+	list.Option
+	// Draft publishes the draft releases.
+	Draft bool
+	// PreRelease promotes the prereleases to the releases.
+	PreRelease bool
+	// RequiredAssets are the names of the assets that must be uploaded to
+	// the release before publishing.
+	RequiredAssets []string
+	// MakeLatest sets the published release as the latest release.
+	MakeLatest bool
+	DryRun     bool
+}
+
+var ErrNotFound = fmt.Errorf("not found")
+
+func missingAssets(v *github.Release, names []string) []string {
+	exists := map[string]bool{}
+	for _, a := range v.Assets {
+		exists[a.Name] = true
+	}
+
+	missing := []string{}
+	for _, name := range names {
+		if !exists[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// changes returns the changes to publish the release. it returns nil if the
+// release has nothing to publish. the published release is also changed if
+// o.MakeLatest is true.
+func changes(v *github.Release, o *Option) *edit.Option {
+	c := &edit.Option{
+		DryRun: o.DryRun,
+	}
+	if o.Draft && v.Draft {
+		c.Draft = new(bool)
+	}
+	if o.PreRelease && v.PreRelease {
+		c.PreRelease = new(bool)
+	}
+	// the draft and the prerelease cannot be the latest release
+	if o.MakeLatest && (!v.Draft || c.Draft != nil) && (!v.PreRelease || c.PreRelease != nil) {
+		c.MakeLatest = "true"
+	}
+	if c.Draft == nil && c.PreRelease == nil && c.MakeLatest == "" {
+		return nil
+	}
+	return c
+}
+
+// isLatestOnly returns true if the changes only set the release as the
+// latest release.
+func isLatestOnly(c *edit.Option) bool {
+	return c.Draft == nil && c.PreRelease == nil
+}
+
+func publish(ghc *github.Client, v *github.Release, c *edit.Option) (*github.Release, error) {
+	log.Debug("publish release %d", v.ID)
+	if c.DryRun {
+		r := *v
+		if c.Draft != nil {
+			r.Draft = *c.Draft
+		}
+		if c.PreRelease != nil {
+			r.PreRelease = *c.PreRelease
+		}
+		return &r, nil
+	} else if r, err := ghc.UpdateRelease(v.ID, &c.UpdateReleaseParams); err != nil {
+		return nil, err
+	} else if r == nil {
+		return nil, ErrNotFound
+	} else {
+		return r, nil
+	}
+}
+
+func Release(ghc *github.Client, id int, o *Option) (*github.Release, error) {
+	v, err := ghc.GetRelease(id)
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, ErrNotFound
+	} else if missing := missingAssets(v, o.RequiredAssets); len(missing) > 0 {
+		return nil, fmt.Errorf("release %d does not have the required assets %q", v.ID, missing)
+	}
+
+	c := changes(v, o)
+	if c == nil {
+		log.Debug("ignore release that has already been published: %d", v.ID)
+		return v, nil
+	}
+	return publish(ghc, v, c)
+}
+
+// Releases publishes the releases that match the list.Option. the releases
+// that do not have the required assets are not published, and the error is
+// returned after publishing the others. if o.MakeLatest is true, the
+// published release is set as the latest release only if there is no release
+// to publish.
+func Releases(ghc *github.Client, o *Option) ([]*github.Release, error) {
+	releases, err := list.AllReleases(ghc, &o.Option)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []*github.Release{}
+	latest := []*github.Release{}
+	skipped := []int{}
+	for _, v := range releases {
+		if c := changes(v, o); c == nil {
+			log.Debug("ignore release that has already been published: %d", v.ID)
+		} else if missing := missingAssets(v, o.RequiredAssets); len(missing) > 0 {
+			log.Errorf("ignore release that does not have the required assets %q: %d", missing, v.ID)
+			skipped = append(skipped, v.ID)
+		} else if isLatestOnly(c) {
+			latest = append(latest, v)
+		} else {
+			targets = append(targets, v)
+		}
+	}
+	if len(targets) == 0 {
+		targets = latest
+	}
+	if o.MakeLatest && len(targets) > 1 {
+		return nil, fmt.Errorf("cannot set %d releases as the latest release", len(targets))
+	}
+
+	list := []*github.Release{}
+	for _, v := range targets {
+		if v, err = publish(ghc, v, changes(v, o)); err != nil {
+			return list, err
+		}
+		list = append(list, v)
+	}
+
+	if len(skipped) > 0 {
+		return list, fmt.Errorf("releases %v do not have the required assets", skipped)
+	}
+	return list, nil
+}
@@ -0,0 +1,144 @@
+package publish
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/edit"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_changes(t *testing.T) {
+	yes := "true"
+	for _, v := range []struct {
+		name    string
+		release *github.Release
+		option  *Option
+		changes *edit.Option
+	}{
+		{
+			name:    "publish the draft",
+			release: &github.Release{Draft: true},
+			option:  &Option{Draft: true, PreRelease: true},
+			changes: &edit.Option{UpdateReleaseParams: github.UpdateReleaseParams{Draft: new(bool)}},
+		},
+		{
+			name:    "promote the prerelease and make it latest",
+			release: &github.Release{PreRelease: true},
+			option:  &Option{PreRelease: true, MakeLatest: true},
+			changes: &edit.Option{UpdateReleaseParams: github.UpdateReleaseParams{PreRelease: new(bool), MakeLatest: yes}},
+		},
+		{
+			name:    "ignore the draft if only the prereleases are published",
+			release: &github.Release{Draft: true, PreRelease: true},
+			option:  &Option{PreRelease: true, MakeLatest: true},
+			changes: &edit.Option{UpdateReleaseParams: github.UpdateReleaseParams{PreRelease: new(bool)}},
+		},
+		{
+			name:    "ignore the published release",
+			release: &github.Release{},
+			option:  &Option{Draft: true, PreRelease: true},
+		},
+		{
+			name:    "make the published release latest",
+			release: &github.Release{},
+			option:  &Option{Draft: true, MakeLatest: true, DryRun: true},
+			changes: &edit.Option{UpdateReleaseParams: github.UpdateReleaseParams{MakeLatest: yes}, DryRun: true},
+		},
+		{
+			name:    "the draft cannot be latest",
+			release: &github.Release{Draft: true},
+			option:  &Option{PreRelease: true, MakeLatest: true},
+		},
+	} {
+		assert.Equal(t, v.changes, changes(v.release, v.option), v.name)
+	}
+}
+
+func Test_Releases(t *testing.T) {
+	releases := ""
+	published := []string{}
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/releases":
+			_, _ = w.Write([]byte(releases))
+
+		case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/repos/owner/repo/releases/"):
+			p := map[string]interface{}{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			b, _ := json.Marshal(p)
+			published = append(published, strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/releases/")+" "+string(b))
+			_, _ = w.Write([]byte(`{"id": 1}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	for _, v := range []struct {
+		name      string
+		releases  string
+		option    *Option
+		published []string
+		err       bool
+	}{
+		{
+			name:     "publish the drafts and ignore the published release",
+			releases: `[{"id": 1, "draft": true}, {"id": 2}, {"id": 3, "draft": true}]`,
+			option:   &Option{Draft: true},
+			published: []string{
+				`1 {"draft":false}`,
+				`3 {"draft":false}`,
+			},
+		},
+		{
+			name:     "publish the others and return error if the required assets are missing",
+			releases: `[{"id": 1, "draft": true, "assets": [{"name": "foo"}]}, {"id": 2, "draft": true}]`,
+			option:   &Option{Draft: true, RequiredAssets: []string{"foo"}},
+			published: []string{
+				`1 {"draft":false}`,
+			},
+			err: true,
+		},
+		{
+			name:     "make the published release latest if there is no release to publish",
+			releases: `[{"id": 1}]`,
+			option:   &Option{Draft: true, MakeLatest: true},
+			published: []string{
+				`1 {"make_latest":"true"}`,
+			},
+		},
+		{
+			name:     "make only the published draft latest",
+			releases: `[{"id": 1, "draft": true}, {"id": 2}]`,
+			option:   &Option{Draft: true, MakeLatest: true},
+			published: []string{
+				`1 {"draft":false,"make_latest":"true"}`,
+			},
+		},
+		{
+			name:     "cannot make multiple releases latest",
+			releases: `[{"id": 1, "draft": true}, {"id": 2, "draft": true}]`,
+			option:   &Option{Draft: true, MakeLatest: true},
+			err:      true,
+		},
+	} {
+		releases = v.releases
+		published = []string{}
+		_, err := Releases(ghc, v.option)
+		if v.err {
+			assert.Error(t, err, v.name)
+		} else {
+			assert.NoError(t, err, v.name)
+		}
+		if v.published == nil {
+			v.published = []string{}
+		}
+		assert.Equal(t, v.published, published, v.name)
+	}
+}
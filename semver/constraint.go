@@ -0,0 +1,200 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+type comparator struct {
+	op string
+	v  *Version
+}
+
+func (c *comparator) match(v *Version) bool {
+	n := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	}
+	// "<="
+	return n <= 0
+}
+
+// Constraint is the set of the comparisons that the version must satisfy.
+// the comparisons are separated by spaces. (e.g. ">=1.4 <2", "~1.2", "^0.9")
+//
+// the following operators are available:
+//
+//	=, !=, >, >=, <, <=   compare the version by the precedence.
+//	~1.2.3                >=1.2.3 <1.3.0, ~1.2 is >=1.2.0 <1.3.0 and
+//	                      ~1 is >=1.0.0 <2.0.0.
+//	^1.2.3                >=1.2.3 <2.0.0, ^0.9 is >=0.9.0 <0.10.0 and
+//	                      ^0.0.3 is >=0.0.3 <0.0.4.
+//	1.2                   the version without the operator is same as ~1.2.
+//	                      the full version (e.g. 1.2.3) is same as =1.2.3.
+//	*                     any version.
+//
+// the missing minor and patch numbers can also be written as "x" or "*".
+// the upper bound that has no prerelease identifiers does not match the
+// prereleases of it. (e.g. <2 does not match 2.0.0-rc.1)
+type Constraint struct {
+	str  string
+	list []*comparator
+}
+
+var operators = []string{">=", "<=", "!=", "=", ">", "<", "~", "^"}
+
+// parsePartial parses the version that may miss the minor and patch numbers,
+// and returns the number of the specified numbers.
+func parsePartial(s string) (*Version, int, error) {
+	str := strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	for _, wildcard := range []string{".x", ".X", ".*"} {
+		for strings.HasSuffix(str, wildcard) {
+			str = strings.TrimSuffix(str, wildcard)
+		}
+	}
+
+	v, err := Parse(str)
+	if err != nil {
+		return nil, 0, err
+	}
+	core := str
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	return v, strings.Count(core, ".") + 1, nil
+}
+
+// upperBound returns the lowest version of the major, minor and patch.
+func upperBound(major, minor, patch int) *Version {
+	return &Version{
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		PreRelease: []string{"0"},
+	}
+}
+
+func parseComparator(s string) ([]*comparator, error) {
+	if s == "*" || s == "x" || s == "X" {
+		return nil, nil
+	}
+
+	op := ""
+	for _, v := range operators {
+		if strings.HasPrefix(s, v) {
+			op = v
+			break
+		}
+	}
+	v, n, err := parsePartial(s[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "":
+		if n == 3 {
+			return []*comparator{{op: "=", v: v}}, nil
+		}
+		fallthrough
+	case "~":
+		upper := upperBound(v.Major+1, 0, 0)
+		if n > 1 {
+			upper = upperBound(v.Major, v.Minor+1, 0)
+		}
+		return []*comparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+
+	case "^":
+		var upper *Version
+		switch {
+		case v.Major > 0 || n == 1:
+			upper = upperBound(v.Major+1, 0, 0)
+		case v.Minor > 0 || n == 2:
+			upper = upperBound(0, v.Minor+1, 0)
+		default:
+			upper = upperBound(0, 0, v.Patch+1)
+		}
+		return []*comparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+
+	case "<":
+		if !v.IsPreRelease() {
+			v = upperBound(v.Major, v.Minor, v.Patch)
+		}
+	case "<=", ">":
+		// the missing numbers are the wildcards (e.g. <=1.2 is <1.3.0)
+		if n < 3 && !v.IsPreRelease() {
+			if op == "<=" {
+				op = "<"
+			} else {
+				op = ">="
+			}
+			if n == 1 {
+				v = upperBound(v.Major+1, 0, 0)
+			} else {
+				v = upperBound(v.Major, v.Minor+1, 0)
+			}
+		}
+	}
+	return []*comparator{{op: op, v: v}}, nil
+}
+
+// ParseConstraint parses the string as the constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{
+		str:  strings.TrimSpace(s),
+		list: []*comparator{},
+	}
+	if c.str == "" {
+		return nil, fmt.Errorf("empty constraint")
+	}
+
+	fields := strings.Fields(c.str)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// the operator may be separated from the version (e.g. ">= 1.4")
+		for _, op := range operators {
+			if f == op && i+1 < len(fields) {
+				i++
+				f += fields[i]
+				break
+			}
+		}
+
+		list, err := parseComparator(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+		}
+		c.list = append(c.list, list...)
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.str
+}
+
+// Check returns true if the version satisfies all the comparisons.
+func (c *Constraint) Check(v *Version) bool {
+	for _, cmp := range c.list {
+		if !cmp.match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match parses the string and returns true if it is a version that satisfies
+// the constraint.
+func (c *Constraint) Match(s string) bool {
+	v, err := Parse(s)
+	return err == nil && c.Check(v)
+}
@@ -0,0 +1,98 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseConstraint(t *testing.T) {
+	for _, v := range []struct {
+		constraint string
+		match      []string
+		unmatch    []string
+	}{
+		{
+			constraint: ">=1.4 <2",
+			match:      []string{"1.4.0", "v1.10.3", "1.99.0"},
+			unmatch:    []string{"1.3.9", "2.0.0", "2.0.0-rc.1", "1.4.0-rc.1"},
+		},
+		{
+			constraint: ">= 1.4  < 2",
+			match:      []string{"1.4.0", "1.99.0"},
+			unmatch:    []string{"1.3.9", "2.0.0"},
+		},
+		{
+			constraint: "~1.2",
+			match:      []string{"1.2.0", "1.2.9"},
+			unmatch:    []string{"1.1.9", "1.3.0", "1.3.0-rc.1"},
+		},
+		{
+			constraint: "~1.2.3",
+			match:      []string{"1.2.3", "1.2.10"},
+			unmatch:    []string{"1.2.2", "1.3.0"},
+		},
+		{
+			constraint: "~1",
+			match:      []string{"1.0.0", "1.9.0"},
+			unmatch:    []string{"0.9.0", "2.0.0"},
+		},
+		{
+			constraint: "^0.9",
+			match:      []string{"0.9.0", "0.9.5"},
+			unmatch:    []string{"0.8.0", "0.10.0", "1.0.0"},
+		},
+		{
+			constraint: "^1.2.3",
+			match:      []string{"1.2.3", "1.9.0"},
+			unmatch:    []string{"1.2.2", "2.0.0"},
+		},
+		{
+			constraint: "^0.0.3",
+			match:      []string{"0.0.3"},
+			unmatch:    []string{"0.0.4", "0.1.0"},
+		},
+		{
+			constraint: "1.2.x",
+			match:      []string{"1.2.0", "1.2.9"},
+			unmatch:    []string{"1.3.0"},
+		},
+		{
+			constraint: "v1.2.3-rc.1",
+			match:      []string{"1.2.3-rc.1", "1.2.3-rc.1+build"},
+			unmatch:    []string{"1.2.3", "1.2.3-rc.2"},
+		},
+		{
+			constraint: "<=1.2 !=1.1.0",
+			match:      []string{"1.2.9", "1.0.0"},
+			unmatch:    []string{"1.3.0", "1.1.0"},
+		},
+		{
+			constraint: ">1.2",
+			match:      []string{"1.3.0"},
+			unmatch:    []string{"1.2.9"},
+		},
+		{
+			constraint: "*",
+			match:      []string{"0.0.1", "10.0.0-rc.1"},
+			unmatch:    []string{"latest"},
+		},
+	} {
+		c, err := ParseConstraint(v.constraint)
+		assert.NoError(t, err, v.constraint)
+		assert.Equal(t, v.constraint, c.String())
+		for _, s := range v.match {
+			assert.True(t, c.Match(s), "%q must match %q", v.constraint, s)
+		}
+		for _, s := range v.unmatch {
+			assert.False(t, c.Match(s), "%q must not match %q", v.constraint, s)
+		}
+	}
+
+	// test that returns error
+	for _, s := range []string{"", "  ", ">=", "~foo", ">=1.4 <", "=>1.0", "1.2.3.4"} {
+		c, err := ParseConstraint(s)
+		assert.Nil(t, c, s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,174 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the semantic version. the "v" prefix and the missing minor and
+// patch numbers are accepted. (e.g. v1.2 is 1.2.0)
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease []string
+	Build      string
+}
+
+func parseNumber(s, name string) (int, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid %s version %q", name, s)
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid %s version %q", name, s)
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses the string as the semantic version.
+func Parse(s string) (*Version, error) {
+	str := strings.TrimSpace(s)
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "v"), "V")
+
+	v := &Version{}
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		v.Build = str[i+1:]
+		str = str[:i]
+		for _, id := range strings.Split(v.Build, ".") {
+			if !isIdentifier(id) {
+				return nil, fmt.Errorf("invalid build metadata in %q", s)
+			}
+		}
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.PreRelease = strings.Split(str[i+1:], ".")
+		str = str[:i]
+		for _, id := range v.PreRelease {
+			if !isIdentifier(id) {
+				return nil, fmt.Errorf("invalid prerelease version in %q", s)
+			} else if _, err := strconv.Atoi(id); err == nil && len(id) > 1 && id[0] == '0' {
+				return nil, fmt.Errorf("invalid prerelease version in %q", s)
+			}
+		}
+	}
+
+	arr := strings.Split(str, ".")
+	if len(arr) > 3 {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	var err error
+	for i, p := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if i < len(arr) {
+			name := []string{"major", "minor", "patch"}[i]
+			if *p, err = parseNumber(arr[i], name); err != nil {
+				return nil, fmt.Errorf("invalid version %q: %w", s, err)
+			}
+		}
+	}
+	return v, nil
+}
+
+// MustParse is like Parse but panics if the string cannot be parsed.
+func MustParse(s string) *Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.PreRelease) > 0 {
+		s += "-" + strings.Join(v.PreRelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPreRelease returns true if the version has the prerelease identifiers.
+func (v *Version) IsPreRelease() bool {
+	return len(v.PreRelease) > 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePreRelease compares the prerelease identifiers. the version without
+// the prerelease has the higher precedence.
+func comparePreRelease(a, b []string) int {
+	if len(a) == 0 || len(b) == 0 {
+		return -compareInt(len(a), len(b))
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, xerr := strconv.Atoi(a[i])
+		y, yerr := strconv.Atoi(b[i])
+		switch {
+		case xerr == nil && yerr == nil:
+			if c := compareInt(x, y); c != 0 {
+				return c
+			}
+		case xerr == nil:
+			// the numeric identifier has the lower precedence
+			return -1
+		case yerr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(a), len(b))
+}
+
+// Compare returns -1, 0 or 1 if the version is lower than, equal to, or higher
+// than the other version. the build metadata is ignored.
+func (v *Version) Compare(o *Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	} else if c = compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	} else if c = compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease, o.PreRelease)
+}
+
+// Compare parses the strings and compares them.
+func Compare(a, b string) (int, error) {
+	x, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return x.Compare(y), nil
+}
@@ -0,0 +1,67 @@
+package semver
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	// test that parse the version
+	v, err := Parse("v1.2.3-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, &Version{
+		Major:      1,
+		Minor:      2,
+		Patch:      3,
+		PreRelease: []string{"rc", "1"},
+		Build:      "build.5",
+	}, v)
+	assert.Equal(t, "1.2.3-rc.1+build.5", v.String())
+	assert.True(t, v.IsPreRelease())
+
+	// test that the missing minor and patch are zero
+	v, err = Parse("2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", v.String())
+	v, err = Parse("V2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.1.0", v.String())
+
+	// test that returns error
+	for _, s := range []string{
+		"", "v", "1.2.3.4", "1.02.3", "1.x", "1.2.3-", "1.2.3-rc..1",
+		"1.2.3-01", "1.2.3+", "1.2.3+build_1", "latest",
+	} {
+		v, err = Parse(s)
+		assert.Nil(t, v, s)
+		assert.Error(t, err, s)
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	// test that sort the versions by the precedence
+	list := []string{
+		"1.0.0", "1.0.0-rc.1", "1.0.0-beta.11", "0.9.9", "1.0.0-alpha",
+		"1.0.0-alpha.1", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-alpha.beta",
+		"2.0.0", "1.10.0", "1.2.0",
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return MustParse(list[i]).Compare(MustParse(list[j])) < 0
+	})
+	assert.Equal(t, []string{
+		"0.9.9", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta",
+		"1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0",
+		"1.2.0", "1.10.0", "2.0.0",
+	}, list)
+
+	// test that the build metadata is ignored
+	c, err := Compare("v1.0.0+a", "1.0.0+b")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, c)
+
+	// test that returns error
+	_, err = Compare("1.0.0", "foo")
+	assert.Error(t, err)
+}
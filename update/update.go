@@ -0,0 +1,209 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mah0x211/github-release-admin/download"
+	"github.com/mah0x211/github-release-admin/github"
+	"github.com/mah0x211/github-release-admin/log"
+	"github.com/mah0x211/github-release-admin/semver"
+)
+
+type Option struct {
+	// Option is used to download the asset of the latest release. the asset
+	// that matches the Platform is selected from the assets that match the
+	// name. (default platform: auto) the asset is always verified with the
+	// checksum manifest unless the SHA256 is specified.
+	download.Option
+	// Force updates the executable even if the latest version is not newer
+	// than the current version.
+	Force bool
+}
+
+// Result is the result of the update.
+type Result struct {
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version"`
+	// Updated is true if the executable has been replaced. it is always
+	// false in dry-run.
+	Updated bool   `json:"updated"`
+	Asset   string `json:"asset,omitempty"`
+	Path    string `json:"path"`
+	// Backup is the pathname of the previous executable.
+	Backup string `json:"backup,omitempty"`
+}
+
+// BackupSuffix is appended to the pathname of the previous executable.
+const BackupSuffix = ".old"
+
+// executable returns the pathname of the executable in the downloaded files.
+// if the archive contains multiple files, the file that has the same name as
+// the target is selected.
+func executable(r *download.Result, target string) (string, error) {
+	if r.Extracted == nil {
+		// the asset is not an archive
+		return r.Path, nil
+	}
+
+	list := []string{}
+	for _, pathname := range r.Extracted {
+		if fi, err := os.Lstat(pathname); err != nil {
+			return "", err
+		} else if fi.Mode().IsRegular() {
+			list = append(list, pathname)
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+
+	names := []string{}
+	name := strings.TrimSuffix(filepath.Base(target), ".exe")
+	for _, pathname := range list {
+		base := filepath.Base(pathname)
+		if strings.TrimSuffix(base, ".exe") == name {
+			return pathname, nil
+		}
+		names = append(names, base)
+	}
+	return "", fmt.Errorf("executable %q is not found in %s: %q", name, r.Name, names)
+}
+
+func copyFile(dst, src string, mode os.FileMode) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	} else if _, err = io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	} else if err = w.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
+
+// replace replaces the target with the new executable. the target is kept as
+// the backup file, and then it is replaced by renaming the new file that is
+// copied into the same directory.
+func replace(target, src string) (string, error) {
+	mode := os.FileMode(0755)
+	fi, err := os.Stat(target)
+	if err == nil {
+		mode = fi.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	tmp := target + ".new"
+	if err = copyFile(tmp, src, mode); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	backup := ""
+	if fi != nil {
+		backup = target + BackupSuffix
+		if err = os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return "", err
+		}
+		// the hard link keeps the target in place until it is replaced
+		if err = os.Link(target, backup); err != nil {
+			log.Debug("failed to link %s: %v", backup, err)
+			if err = copyFile(backup, target, mode); err != nil {
+				os.Remove(tmp)
+				return "", err
+			}
+		}
+	}
+
+	if err = os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return backup, nil
+}
+
+// Update replaces the executable at the pathname with the asset of the latest
+// release if the latest version is newer than the current version.
+func Update(ghc *github.Client, current, name, pathname string, o *Option) (*Result, error) {
+	cur, err := semver.Parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("invalid current version: %w", err)
+	}
+
+	v, err := ghc.GetReleaseLatest()
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, &download.NotFoundError{Resource: "release", Name: "latest"}
+	}
+	latest, err := semver.Parse(v.TagName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of the latest release: %w", err)
+	}
+
+	res := &Result{
+		CurrentVersion: cur.String(),
+		LatestVersion:  latest.String(),
+		Path:           pathname,
+	}
+	if latest.Compare(cur) <= 0 && !o.Force {
+		log.Debug("%s is up to date: %s", pathname, cur)
+		return res, nil
+	}
+
+	tmpdir, err := os.MkdirTemp("", "ghr-update-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	do := o.Option
+	do.Dirname = tmpdir
+	do.SaveAs = ""
+	do.Writer = nil
+	do.All = false
+	do.Extract = true
+	do.ExtractDir = filepath.Join(tmpdir, "extract")
+	if do.SHA256 == "" {
+		// the asset must be verified with the checksum manifest
+		do.Verify = true
+	}
+	if do.Platform == nil {
+		if do.Platform, err = download.ParsePlatform("auto"); err != nil {
+			return nil, err
+		}
+	}
+
+	list, err := download.Release(ghc, v.ID, name, &do)
+	if err != nil {
+		return nil, err
+	}
+	res.Asset = list[0].Name
+	if o.DryRun {
+		// the executable is not replaced
+		return res, nil
+	}
+
+	src, err := executable(list[0], pathname)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("replace %s with %s", pathname, src)
+	if res.Backup, err = replace(pathname, src); err != nil {
+		return nil, err
+	}
+	res.Updated = true
+	return res, nil
+}
@@ -0,0 +1,121 @@
+package update
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mah0x211/github-release-admin/download"
+	"github.com/mah0x211/github-release-admin/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_replace(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "tool")
+	src := filepath.Join(dir, "src")
+	assert.NoError(t, ioutil.WriteFile(src, []byte("new"), 0644))
+
+	// test that create the target if not exists
+	backup, err := replace(target, src)
+	assert.NoError(t, err)
+	assert.Empty(t, backup)
+	fi, err := os.Stat(target)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	// test that replace the target and keep the backup
+	assert.NoError(t, ioutil.WriteFile(src, []byte("newer"), 0644))
+	assert.NoError(t, os.Chmod(target, 0700))
+	backup, err = replace(target, src)
+	assert.NoError(t, err)
+	assert.Equal(t, target+BackupSuffix, backup)
+	b, err := ioutil.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", string(b))
+	b, err = ioutil.ReadFile(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(b))
+	fi, err = os.Stat(target)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+	assert.NoFileExists(t, target+".new")
+}
+
+func Test_executable(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"README.md", "tool", "LICENSE"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	// test that returns the downloaded file if the asset is not an archive
+	pathname, err := executable(&download.Result{Path: "tool-linux-amd64"}, "/bin/tool")
+	assert.NoError(t, err)
+	assert.Equal(t, "tool-linux-amd64", pathname)
+
+	// test that returns the file that has the same name as the target
+	r := &download.Result{
+		Name: "tool.tar.gz",
+		Extracted: []string{
+			filepath.Join(dir, "README.md"),
+			filepath.Join(dir, "tool"),
+			filepath.Join(dir, "LICENSE"),
+		},
+	}
+	pathname, err = executable(r, "/usr/local/bin/tool")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "tool"), pathname)
+
+	// test that returns error if the executable is not found
+	_, err = executable(r, "/usr/local/bin/other")
+	assert.Error(t, err)
+}
+
+func Test_Update(t *testing.T) {
+	ghc, ts := testutil.NewClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/latest":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.2.0"}`)
+		case "/repos/owner/repo/releases/1":
+			fmt.Fprintf(w, `{"id": 1, "tag_name": "v1.2.0", "assets": [
+				{"id": 2, "name": "tool_%s_%s.tar.gz"},
+				{"id": 3, "name": "SHA256SUMS"}
+			]}`, runtime.GOOS, runtime.GOARCH)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	target := filepath.Join(t.TempDir(), "tool")
+
+	// test that does not update if the current version is up to date
+	res, err := Update(ghc, "v1.2.0", "", target, &Option{})
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{
+		CurrentVersion: "1.2.0",
+		LatestVersion:  "1.2.0",
+		Path:           target,
+	}, res)
+	assert.NoFileExists(t, target)
+
+	// test that does not report the update in dry-run
+	res, err = Update(ghc, "v1.1.0", "", target, &Option{
+		Option: download.Option{DryRun: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{
+		CurrentVersion: "1.1.0",
+		LatestVersion:  "1.2.0",
+		Asset:          fmt.Sprintf("tool_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH),
+		Path:           target,
+	}, res)
+	assert.NoFileExists(t, target)
+
+	// test that returns error if the current version is invalid
+	_, err = Update(ghc, "latest", "", target, &Option{})
+	assert.Error(t, err)
+}
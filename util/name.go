@@ -0,0 +1,38 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CompileName compiles the name as regular expressions if asRegex is true. it
+// is compiled as POSIX ERE (egrep) if asPosix is also true. it returns nil if
+// the name is empty or it should be matched exactly.
+func CompileName(name string, asRegex, asPosix bool) (*regexp.Regexp, error) {
+	if !asRegex || name == "" {
+		return nil, nil
+	}
+
+	var re *regexp.Regexp
+	var err error
+	if asPosix {
+		re, err = regexp.CompilePOSIX(name)
+	} else {
+		re, err = regexp.Compile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%q cannot be compiled as regular expression: %w", name, err,
+		)
+	}
+	return re, nil
+}
+
+// MatchName returns true if the s matches the re compiled by CompileName, or
+// the s is equal to the name if the re is nil. the empty name matches any s.
+func MatchName(re *regexp.Regexp, name, s string) bool {
+	if re != nil {
+		return re.MatchString(s)
+	}
+	return name == "" || s == name
+}
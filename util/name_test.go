@@ -0,0 +1,62 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CompileName(t *testing.T) {
+	for _, v := range []struct {
+		name    string
+		asRegex bool
+		asPosix bool
+		match   []string
+		unmatch []string
+	}{
+		{
+			name:    "",
+			asRegex: true,
+			match:   []string{"", "v1.0.0"},
+		},
+		{
+			name:    "v1.0",
+			match:   []string{"v1.0"},
+			unmatch: []string{"v1.0.0", "v1x0"},
+		},
+		{
+			name:    "v1.0",
+			asPosix: true,
+			match:   []string{"v1.0"},
+			unmatch: []string{"v1.0.0"},
+		},
+		{
+			name:    "^v1.0",
+			asRegex: true,
+			match:   []string{"v1.0.0", "v1x0"},
+			unmatch: []string{"v2.0.0"},
+		},
+		{
+			name:    "^v(1|10)",
+			asRegex: true,
+			asPosix: true,
+			match:   []string{"v1.0.0", "v10.0.0"},
+			unmatch: []string{"v2.0.0"},
+		},
+	} {
+		re, err := CompileName(v.name, v.asRegex, v.asPosix)
+		assert.NoError(t, err, v.name)
+		for _, s := range v.match {
+			assert.True(t, MatchName(re, v.name, s), "%q should match %q", v.name, s)
+		}
+		for _, s := range v.unmatch {
+			assert.False(t, MatchName(re, v.name, s), "%q should not match %q", v.name, s)
+		}
+	}
+
+	// test that returns error if the name cannot be compiled
+	_, err := CompileName("(", true, false)
+	assert.Error(t, err)
+	_, err = CompileName("\\d", true, true)
+	assert.Error(t, err)
+}